@@ -0,0 +1,212 @@
+// Package algotest checks search algorithms against the properties every
+// result must have. A new algorithm only needs to be added to
+// algorithm.Algorithms to be covered by the tests of package algorithm:
+//
+//	for _, algo := range algorithm.Algorithms() {
+//		algotest.CheckAlgorithm(t, algo, graphtest.Diamond().Graph(t))
+//	}
+package algotest
+
+import (
+	"backend/algorithm"
+	"backend/analytics"
+	"backend/search"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current results")
+
+// Values of max every element is searched with by CheckAlgorithm
+var MaxPaths = []int{1, 3, 10}
+
+// Longest a single search of a test graph may take
+var Timeout = 10 * time.Second
+
+func Search(t testing.TB, algo string, graph *search.RecipeGraph, target *search.ElementNode, options algorithm.SearchOptions) (*algorithm.SearchResult, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	result, err := algorithm.Search(ctx, algo, target, graph, options)
+	if errors.Is(err, algorithm.ErrTimeout) {
+		t.Fatalf("%s search for %s took longer than %v", algo, target.Name, Timeout)
+	}
+	return result, err
+}
+
+// Searches every element of graph with every value of MaxPaths and checks
+// the results. Elements the search rules make reachable must give a tree,
+// the others ErrUnreachable. A base element is its own tree, a lone leaf
+func CheckAlgorithm(t *testing.T, algo string, graph *search.RecipeGraph) {
+	t.Helper()
+	reachable := Reachable(graph)
+	for _, target := range graph.Elements[1:] {
+		for _, maxPaths := range MaxPaths {
+			options := algorithm.SearchOptions{MaxPaths: maxPaths, Dedup: maxPaths > 1}
+			result, err := Search(t, algo, graph, target, options)
+			switch {
+			case err != nil && !errors.Is(err, algorithm.ErrUnreachable):
+				t.Errorf("%s max=%d: %s: %v", algo, maxPaths, target.Name, err)
+			case slices.Contains(graph.BaseElements, target):
+				if err != nil || len(result.Trees) != 1 || result.Trees[0].Crafts != 0 {
+					t.Errorf("%s max=%d: want the lone base element %s, got %v and %d trees", algo, maxPaths, target.Name, err, len(result.Trees))
+				}
+			case !reachable[target]:
+				if err == nil {
+					t.Errorf("%s max=%d: %s cannot be crafted, got %d trees: %s", algo, maxPaths, target.Name, len(result.Trees), Notation(result.Trees[0]))
+				}
+			case err != nil:
+				t.Errorf("%s max=%d: %s can be crafted, got %v", algo, maxPaths, target.Name, err)
+			default:
+				CheckResult(t, graph, target, options, result)
+			}
+		}
+	}
+}
+
+// Elements the search algorithms can craft from the base elements
+func Reachable(graph *search.RecipeGraph) map[*search.ElementNode]bool {
+	for _, rules := range analytics.RuleSets {
+		if rules.Name == "search" {
+			return analytics.Reachable(graph, rules)
+		}
+	}
+	panic("algotest: no search rule set")
+}
+
+// Checks the properties of a successful search for target:
+//   - there is at least one tree and no more than MaxPaths
+//   - every tree is a valid tree of target, see algorithm.ValidateTree
+//   - the IDs, tiers, depths and crafts of every tree match the graph
+//   - no two trees have the same structure when Dedup is set
+func CheckResult(t testing.TB, graph *search.RecipeGraph, target *search.ElementNode, options algorithm.SearchOptions, result *algorithm.SearchResult) {
+	t.Helper()
+	label := result.Algorithm + " " + target.Name
+	if len(result.Trees) == 0 {
+		t.Errorf("%s: no tree", label)
+	}
+	if len(result.Trees) > options.MaxPaths {
+		t.Errorf("%s: %d trees, max is %d", label, len(result.Trees), options.MaxPaths)
+	}
+
+	seen := make(map[string]int)
+	for i, tree := range result.Trees {
+		if tree.Target != target.Name {
+			t.Errorf("%s: tree %d has target %s", label, i, tree.Target)
+		}
+		if violations := algorithm.ValidateTree(graph, tree, nil); len(violations) > 0 {
+			t.Errorf("%s: tree %d is not valid:\n%s\n%s", label, i, algorithm.FormatViolations(violations), Notation(tree))
+			continue
+		}
+		CheckTreeFields(t, graph, tree)
+
+		if !options.Dedup {
+			continue
+		}
+		hash := algorithm.CanonicalHash(tree)
+		if first, ok := seen[hash]; ok {
+			t.Errorf("%s: trees %d and %d are the same: %s", label, first, i, Notation(tree))
+		}
+		seen[hash] = i
+	}
+}
+
+// Checks the fields of tree that are derived from the graph and its shape
+func CheckTreeFields(t testing.TB, graph *search.RecipeGraph, tree *algorithm.RecipeTree) {
+	t.Helper()
+	depth, crafts := 0, 0
+	var walk func(node *algorithm.TreeNode, level int)
+	walk = func(node *algorithm.TreeNode, level int) {
+		element := graph.Elements[node.ID]
+		if node.Tier != element.Tier {
+			t.Errorf("%s: %s has tier %d, not %d", tree.Target, node.Name, node.Tier, element.Tier)
+		}
+		if node.Depth != level {
+			t.Errorf("%s: %s has depth %d, not %d", tree.Target, node.Name, node.Depth, level)
+		}
+		if len(node.Ingredients) == 0 && node.Recipe != -1 {
+			t.Errorf("%s: leaf %s has recipe %d, not -1", tree.Target, node.Name, node.Recipe)
+		}
+		if len(node.Ingredients) > 0 {
+			crafts++
+		}
+		depth = max(depth, level)
+		for _, ingredient := range node.Ingredients {
+			walk(ingredient, level+1)
+		}
+	}
+	walk(tree.Root, 0)
+	if tree.Depth != depth {
+		t.Errorf("%s: depth is %d, not %d", tree.Target, tree.Depth, depth)
+	}
+	if tree.Crafts != crafts {
+		t.Errorf("%s: crafts is %d, not %d", tree.Target, tree.Crafts, crafts)
+	}
+}
+
+// Short form of a tree, e.g. Brick(Mud(Water, Earth), Fire)
+func Notation(tree *algorithm.RecipeTree) string {
+	var b strings.Builder
+	var write func(node *algorithm.TreeNode)
+	write = func(node *algorithm.TreeNode) {
+		b.WriteString(node.Name)
+		if len(node.Ingredients) == 0 {
+			return
+		}
+		b.WriteString("(")
+		for i, ingredient := range node.Ingredients {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			write(ingredient)
+		}
+		b.WriteString(")")
+	}
+	write(tree.Root)
+	return b.String()
+}
+
+// Compares got with testdata/<name>.golden of the package under test. With
+// -update the file is rewritten instead
+func Golden(t testing.TB, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s, run the test with -update to create it: %v", path, err)
+	}
+	if got == string(want) {
+		return
+	}
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+	for i := range max(len(gotLines), len(wantLines)) {
+		var gotLine, wantLine string
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if gotLine != wantLine {
+			t.Errorf("%s differs at line %d, run the test with -update if the change is expected\ngot:  %s\nwant: %s", path, i+1, gotLine, wantLine)
+			return
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package algorithm
+
+import (
+	"backend/search"
+	"container/heap"
+	"context"
+	"fmt"
+)
+
+// Maximum number of partial trees taken from the open list before giving up
+const maxAStarExpansions = 200000
+
+// A partial recipe tree. Every state records one craft on top of its parent,
+// the crafts are made in pre-order so the tree can be rebuilt from the chain
+type aStarState struct {
+	parent  *aStarState
+	element *search.ElementNode // Element crafted in this step, nil for the start state
+	recipe  int                 // Index of the recipe used in ElementNode.Recipes
+
+	open []*search.ElementNode // Elements still to be crafted. The last one is crafted next
+	g    int                   // Crafts made so far
+	f    int                   // g plus the lower bound of crafts left for the open elements
+}
+
+type aStarQueue []*aStarState
+
+func (q aStarQueue) Len() int { return len(q) }
+func (q aStarQueue) Less(i, j int) bool {
+	if q[i].f != q[j].f {
+		return q[i].f < q[j].f
+	}
+	// Prefer the states closest to completion
+	return len(q[i].open) < len(q[j].open)
+}
+func (q aStarQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *aStarQueue) Push(x any)   { *q = append(*q, x.(*aStarState)) }
+func (q *aStarQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Lower bound of the number of crafts needed for every element. A tree needs
+// at least as many crafts as its depth, so this is the smallest depth of a
+// tree of usable recipes. Elements without any such tree are left out
+func craftLowerBounds(graph *search.RecipeGraph) map[int]int {
+	bounds := make(map[int]int)
+	pending := make(map[int]bool)
+
+	var bound func(*search.ElementNode) (int, bool)
+	bound = func(node *search.ElementNode) (int, bool) {
+		if isBaseElement(node) {
+			return 0, true
+		}
+		if b, ok := bounds[node.ID]; ok {
+			return b, true
+		}
+		if pending[node.ID] {
+			return 0, false
+		}
+		pending[node.ID] = true
+
+		best, found := 0, false
+		for _, recipe := range node.Recipes {
+			if !IsUsableRecipe(node, recipe) {
+				continue
+			}
+			b0, ok0 := bound(recipe[0])
+			b1, ok1 := bound(recipe[1])
+			if !ok0 || !ok1 {
+				continue
+			}
+			if b := 1 + max(b0, b1); !found || b < best {
+				best, found = b, true
+			}
+		}
+		if found {
+			bounds[node.ID] = best
+		}
+		return best, found
+	}
+
+	for _, element := range graph.Elements[1:] {
+		bound(element)
+	}
+	return bounds
+}
+
+// A* over partial recipe trees. A state is expanded by crafting one of its
+// open elements with every usable recipe, and f adds the admissible craft
+// lower bound of every open element. Trees come out of the open list in order
+// of their number of crafts, so the first maxPaths goals are the cheapest trees.
+// Like BFS, nodeVisited counts the elements expanded and the base elements
+// they use, not the partial trees
+func AStar(ctx context.Context, target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, dedup bool, nodeVisited *int, stats *StatsCollector) []*RecipeTree {
+	key := CacheKey{Algorithm: "astar", Element: target.ID, MaxPaths: maxPaths, Dedup: dedup}
+	return cachedSearch(ctx, graph, key, nodeVisited, stats, func() []*RecipeTree {
+		return aStar(ctx, target, graph, maxPaths, dedup, nodeVisited, stats)
+	})
+}
+
+func aStar(ctx context.Context, target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, dedup bool, nodeVisited *int, stats *StatsCollector) []*RecipeTree {
+	if isBaseElement(target) {
+		*nodeVisited++
+		return []*RecipeTree{NewRecipeTree(NewLeaf(target))}
+	}
+
+	bounds := craftLowerBounds(graph)
+	h, ok := bounds[target.ID]
+	if !ok {
+		return []*RecipeTree{}
+	}
+
+	queue := &aStarQueue{{
+		open: []*search.ElementNode{target},
+		f:    h,
+	}}
+	trees := make([]*RecipeTree, 0)
+	kept := newCanonicalSet(dedup)
+
+	states := 0
+	for queue.Len() > 0 && states < maxAStarExpansions && ctx.Err() == nil {
+		state := heap.Pop(queue).(*aStarState)
+		states++
+
+		if len(state.open) == 0 {
+			root := state.tree(target)
+			if !kept.add(root) {
+				stats.addDuplicates(1)
+				continue
+			}
+			trees = append(trees, NewRecipeTree(root))
+			if maxPaths > 0 && len(trees) >= maxPaths {
+				break
+			}
+			continue
+		}
+
+		node := state.open[len(state.open)-1]
+		rest := state.open[:len(state.open)-1]
+		*nodeVisited++
+		for i, recipe := range node.Recipes {
+			if !stats.checkRecipe(node, recipe) {
+				continue
+			}
+			b0, ok0 := bounds[recipe[0].ID]
+			b1, ok1 := bounds[recipe[1].ID]
+			if (!ok0 && !isBaseElement(recipe[0])) || (!ok1 && !isBaseElement(recipe[1])) {
+				continue
+			}
+
+			// Ingredient 0 goes on top so the crafts stay in pre-order
+			open := make([]*search.ElementNode, len(rest), len(rest)+2)
+			copy(open, rest)
+			for _, ingredient := range []*search.ElementNode{recipe[1], recipe[0]} {
+				if isBaseElement(ingredient) {
+					*nodeVisited++
+				} else {
+					open = append(open, ingredient)
+				}
+			}
+
+			heap.Push(queue, &aStarState{
+				parent:  state,
+				element: node,
+				recipe:  i,
+				open:    open,
+				g:       state.g + 1,
+				f:       state.f - bounds[node.ID] + 1 + b0 + b1,
+			})
+		}
+	}
+
+	if states >= maxAStarExpansions {
+		fmt.Printf("Warning: Reached max expansions (%d) for %s\n", maxAStarExpansions, target.Name)
+	}
+	return trees
+}
+
+// Rebuilds the recipe tree of a finished state
+func (state *aStarState) tree(target *search.ElementNode) *TreeNode {
+	crafts := make([]*aStarState, state.g)
+	for s := state; s.parent != nil; s = s.parent {
+		crafts[s.g-1] = s
+	}
+
+	next := 0
+	var build func(*search.ElementNode) *TreeNode
+	build = func(node *search.ElementNode) *TreeNode {
+		if isBaseElement(node) {
+			return NewLeaf(node)
+		}
+		craft := crafts[next]
+		next++
+		recipe := node.Recipes[craft.recipe]
+		ingredient0 := build(recipe[0])
+		ingredient1 := build(recipe[1])
+		return NewCraft(node, craft.recipe, ingredient0, ingredient1)
+	}
+	return build(target)
+}
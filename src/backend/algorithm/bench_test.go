@@ -0,0 +1,70 @@
+package algorithm_test
+
+import (
+	"backend/algorithm"
+	"backend/algorithm/algotest"
+	"backend/search"
+	"backend/search/graphtest"
+	"context"
+	"fmt"
+	"testing"
+)
+
+// Tiers of the snapshot benchmarked, the first craftable element of each is
+// the target
+var benchmarkTiers = []int{2, 4, 6, 8}
+
+var benchmarkMaxPaths = []int{1, 5, 25}
+
+// go test ./algorithm -run '^$' -bench . -benchmem
+//
+// Searches skip the shared caches, so every iteration runs in full.
+// nodes/op is the number of nodes visited, crafts/op the size of the
+// first tree
+func BenchmarkSearch(b *testing.B) {
+	graph := graphtest.Snapshot(b)
+	targets := benchmarkTargets(b, graph)
+	for _, algo := range algorithm.Algorithms() {
+		for _, target := range targets {
+			for _, maxPaths := range benchmarkMaxPaths {
+				name := fmt.Sprintf("%s/tier%d-%s/max%d", algo, target.Tier, target.Name, maxPaths)
+				b.Run(name, func(b *testing.B) {
+					benchmarkSearch(b, algo, graph, target, maxPaths)
+				})
+			}
+		}
+	}
+}
+
+func benchmarkSearch(b *testing.B, algo string, graph *search.RecipeGraph, target *search.ElementNode, maxPaths int) {
+	options := algorithm.SearchOptions{MaxPaths: maxPaths, Dedup: maxPaths > 1, NoCache: true}
+	var result *algorithm.SearchResult
+	for b.Loop() {
+		var err error
+		result, err = algorithm.Search(context.Background(), algo, target, graph, options)
+		if err != nil {
+			b.Fatalf("%s %s: %v", algo, target.Name, err)
+		}
+	}
+	b.ReportMetric(float64(result.VisitedNodes), "nodes/op")
+	b.ReportMetric(float64(result.Trees[0].Crafts), "crafts/op")
+}
+
+func benchmarkTargets(b *testing.B, graph *search.RecipeGraph) []*search.ElementNode {
+	reachable := algotest.Reachable(graph)
+	targets := make([]*search.ElementNode, 0, len(benchmarkTiers))
+	for _, tier := range benchmarkTiers {
+		var target *search.ElementNode
+		for _, element := range graph.Elements[1:] {
+			if element.Tier == tier && reachable[element] {
+				target = element
+				break
+			}
+		}
+		if target == nil {
+			b.Fatalf("the snapshot has no craftable element of tier %d", tier)
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
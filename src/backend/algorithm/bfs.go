@@ -2,13 +2,20 @@ package algorithm
 
 import (
 	"backend/search"
+	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"sync"
 )
 
-var usedElemCombMutex = sync.Mutex{}
-var usedElemComb = make(map[string]map[string]bool)
+// Recipe combinations a search already used, by element and ancestry. Every
+// search has its own
+type usedCombinations map[string]map[string]bool
+
+// Goroutines expanding each level and elements expanded before ReverseBFS gives up
+var BFSThreads = 4
+var BFSMaxIterations = 1000
 
 type AncestryChain struct {
 	Element string
@@ -27,33 +34,33 @@ func getAncSignature(chain *AncestryChain) string {
 	return fmt.Sprintf("%s<-%s", chain.Element, getAncSignature(chain.Parents))
 }
 
-func isElemCombUsed(result string, elem1ID, elem2ID int, ancestryChain *AncestryChain) bool {
+func isElemCombUsed(used usedCombinations, result string, elem1ID, elem2ID int, ancestryChain *AncestryChain) bool {
 	ids := []int{elem1ID, elem2ID}
 	sort.Ints(ids)
 	combKey := fmt.Sprintf("%d+%d", ids[0], ids[1])
 	ancSignature := getAncSignature(ancestryChain)
 	combMapKey := fmt.Sprintf("%s:%s", result, ancSignature)
 
-	if _, exists := usedElemComb[combMapKey]; !exists {
-		usedElemComb[combMapKey] = make(map[string]bool)
+	if _, exists := used[combMapKey]; !exists {
+		used[combMapKey] = make(map[string]bool)
 		return false
 	}
 
-	return usedElemComb[combMapKey][combKey]
+	return used[combMapKey][combKey]
 }
 
-func markElemCombUsed(result string, elem1ID, elem2ID int, ancestryChain *AncestryChain) {
+func markElemCombUsed(used usedCombinations, result string, elem1ID, elem2ID int, ancestryChain *AncestryChain) {
 	ids := []int{elem1ID, elem2ID}
 	sort.Ints(ids)
 	combKey := fmt.Sprintf("%d+%d", ids[0], ids[1])
 	ancSignature := getAncSignature(ancestryChain)
 	combMapKey := fmt.Sprintf("%s:%s", result, ancSignature)
 
-	if _, exists := usedElemComb[combMapKey]; !exists {
-		usedElemComb[combMapKey] = make(map[string]bool)
+	if _, exists := used[combMapKey]; !exists {
+		used[combMapKey] = make(map[string]bool)
 	}
 
-	usedElemComb[combMapKey][combKey] = true
+	used[combMapKey][combKey] = true
 }
 
 type JSONNode struct {
@@ -94,6 +101,37 @@ func isBaseElement(node *search.ElementNode) bool {
 	return false
 }
 
+// Recipes of node that the search algorithms are allowed to use:
+// both ingredients have a lower tier and can be crafted themselves
+func IsUsableRecipe(node *search.ElementNode, recipe []*search.ElementNode) bool {
+	return recipePruneReason(node, recipe) == ""
+}
+
+// The tier rule: an ingredient does not have a lower tier than node
+func BreaksTierRule(node *search.ElementNode, recipe []*search.ElementNode) bool {
+	return recipe[0].Tier >= node.Tier || recipe[1].Tier >= node.Tier
+}
+
+// The no recipe rule: an ingredient cannot be crafted and is not a base
+// element
+func UsesUncraftable(recipe []*search.ElementNode) bool {
+	return (isNoRecipe(recipe[0]) && !isBaseElement(recipe[0])) || (isNoRecipe(recipe[1]) && !isBaseElement(recipe[1]))
+}
+
+// Why a recipe of node is not usable, or "" if it is
+func recipePruneReason(node *search.ElementNode, recipe []*search.ElementNode) string {
+	if len(recipe) != 2 || recipe[0] == nil || recipe[1] == nil {
+		return PruneNoRecipe
+	}
+	if BreaksTierRule(node, recipe) {
+		return PruneTier
+	}
+	if UsesUncraftable(recipe) {
+		return PruneNoRecipe
+	}
+	return ""
+}
+
 type JSONRecipe struct {
 	Ingredients []string `json:"ingredients"`
 	Result      string   `json:"result"`
@@ -116,9 +154,20 @@ type BFSProgressResult struct {
 	nodes        []JSONNode
 	visitedNodes int
 	iteration    int
+
+	// Recipes added to the result and recipes skipped, by reason
+	recipesTried   int
+	prunedTier     int
+	prunedNoRecipe int
+	prunedDedup    int
 }
 
 func ReverseBFS(target *search.ElementNode, pathNumber int) (*GraphJSONWithRecipes, int) {
+	return reverseBFS(context.Background(), target, pathNumber, nil, nil)
+}
+
+// Stops after the current level once ctx is done
+func reverseBFS(ctx context.Context, target *search.ElementNode, pathNumber int, observer *SearchObserver, stats *StatsCollector) (*GraphJSONWithRecipes, int) {
 	if isBaseElement(target) {
 		return nil, 0
 	}
@@ -148,76 +197,76 @@ func ReverseBFS(target *search.ElementNode, pathNumber int) (*GraphJSONWithRecip
 	})
 	// Recipe map to prevent duplicate JSONRecipe
 	addedRecipe := make(map[string]bool)
+	used := make(usedCombinations)
 
-	maxIterations := 1000
+	maxIterations := BFSMaxIterations
 	iteration := 0
 	visitedNodes := 0
 
-	nthreads := 4
-	for len(queue) > 0 && iteration < maxIterations {
-		nextFrontier := make([]QueueItem, 0)
-		taskChannel := make(chan QueueItem)
-		nextFrontierChannel := make(chan QueueItem)
-
+	nthreads := BFSThreads
+	for len(queue) > 0 && iteration < maxIterations && ctx.Err() == nil {
+		// The goroutines only filter the recipes of each item. The used
+		// combinations are then marked in queue order, so the result does
+		// not depend on which goroutine finished first
+		candidates := make([]bfsCandidates, len(queue))
+		tasks := make(chan int)
 		var wg sync.WaitGroup
 		wg.Add(nthreads)
-		progresses := make([]BFSProgressResult, nthreads)
-		for i := range nthreads {
-			progresses[i] = BFSProgressResult{
-				recipes:      make([]JSONRecipe, 0),
-				nodes:        make([]JSONNode, 0),
-				visitedNodes: 0,
-				iteration:    0,
-			}
-			go ProcessQueue(taskChannel, nextFrontierChannel, &progresses[i], &wg)
-		}
-
-		// Receive results from routines
-		go func() {
-			for {
-				item, ok := <-nextFrontierChannel
-				if !ok {
-					return
+		stats.goroutinesStarted(nthreads)
+		for range nthreads {
+			go func() {
+				defer wg.Done()
+				for i := range tasks {
+					candidates[i] = usableRecipes(queue[i].Node)
 				}
-				nextFrontier = append(nextFrontier, item)
-			}
-		}()
-		// Send tasks to routines
-		for _, task := range queue {
-			taskChannel <- task
+			}()
 		}
-		close(taskChannel)
-
-		// All routine done processing this level
+		for i := range queue {
+			tasks <- i
+		}
+		close(tasks)
 		wg.Wait()
-		close(nextFrontierChannel)
-		// Merge the results of each routines
-		for _, progress := range progresses {
-			visitedNodes += progress.visitedNodes
-			iteration += progress.iteration
-
-			// Merge recipes uniquely
-			for _, recipe := range progress.recipes {
-				recipeSignature := fmt.Sprintf("%s=%s+%s@%d", recipe.Result, recipe.Ingredients[0], recipe.Ingredients[1], recipe.Step)
-				if _, exists := addedRecipe[recipeSignature]; !exists {
-					addedRecipe[recipeSignature] = true
-					recipes = append(recipes, recipe)
-				}
+		stats.goroutinesDone(nthreads)
+
+		progress := BFSProgressResult{
+			recipes: make([]JSONRecipe, 0),
+			nodes:   make([]JSONNode, 0),
+		}
+		nextFrontier := make([]QueueItem, 0)
+		for i, item := range queue {
+			addRecipes(used, item, candidates[i], &progress, func(queued QueueItem) {
+				nextFrontier = append(nextFrontier, queued)
+			})
+			observer.emit(SearchEvent{Type: EventNodeVisited, Element: item.Node.Name, Depth: item.Depth, VisitedNodes: visitedNodes + progress.visitedNodes})
+		}
+		visitedNodes += progress.visitedNodes
+		iteration += progress.iteration
+		stats.add(progress.recipesTried, progress.prunedTier, progress.prunedNoRecipe, progress.prunedDedup)
+
+		// Merge recipes uniquely
+		for _, recipe := range progress.recipes {
+			recipeSignature := fmt.Sprintf("%s=%s+%s@%d", recipe.Result, recipe.Ingredients[0], recipe.Ingredients[1], recipe.Step)
+			if _, exists := addedRecipe[recipeSignature]; !exists {
+				addedRecipe[recipeSignature] = true
+				recipes = append(recipes, recipe)
+				observer.emit(SearchEvent{Type: EventRecipeTried, Element: recipe.Result, Ingredients: recipe.Ingredients, Depth: recipe.Step, VisitedNodes: visitedNodes})
 			}
-			// Merge all used nodes in recipes
-			for _, node := range progress.nodes {
-				if !nodesToInclude[node.ID] {
-					nodesToInclude[node.ID] = true
-					nodes = append(nodes, node)
-				}
+		}
+		// Merge all used nodes in recipes
+		for _, node := range progress.nodes {
+			if !nodesToInclude[node.ID] {
+				nodesToInclude[node.ID] = true
+				nodes = append(nodes, node)
 			}
 		}
 
+		observer.emit(SearchEvent{Type: EventLevelDone, Depth: queue[0].Depth, VisitedNodes: visitedNodes})
+
 		queue = nextFrontier
 	}
 
 	if iteration >= maxIterations {
-		fmt.Printf("Warning: Reached max iterations (%d) for path %d\n", maxIterations, pathNumber)
+		warnMaxIterations(maxIterations, pathNumber)
 	}
 
 	return &GraphJSONWithRecipes{
@@ -226,87 +275,94 @@ func ReverseBFS(target *search.ElementNode, pathNumber int) (*GraphJSONWithRecip
 	}, visitedNodes
 }
 
-func ResetCaches() {
-	usedElemComb = make(map[string]map[string]bool)
+// ReverseBFS stopped after the level where it reached maxIterations, it may
+// have missed trees
+func warnMaxIterations(maxIterations int, pathNumber int) {
+	fmt.Printf("Warning: Reached max iterations (%d) for path %d\n", maxIterations, pathNumber)
 }
 
-func ProcessQueue(task chan QueueItem, next chan QueueItem, result *BFSProgressResult, wg *sync.WaitGroup) {
-	defer func() {
-		wg.Done()
-		// fmt.Println("Routine finished")
-	}()
+// Finds at most maxPaths recipe trees for target with ReverseBFS
+func BFS(ctx context.Context, target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, dedup bool, nodeVisited *int, stats *StatsCollector) []*RecipeTree {
+	if slices.Contains(graph.BaseElements, target) {
+		*nodeVisited = 1
+		return []*RecipeTree{NewRecipeTree(NewLeaf(target))}
+	}
 
-	for {
-		item, ok := <-task
-		if !ok {
-			break
-		}
+	key := CacheKey{Algorithm: "bfs", Element: target.ID, MaxPaths: maxPaths, Dedup: dedup}
+	return cachedSearch(ctx, graph, key, nodeVisited, stats, func() []*RecipeTree {
+		big, visited := reverseBFS(ctx, target, 1, nil, stats)
+		*nodeVisited = visited
+		return ExpandTrees(*big, target, maxPaths, dedup, stats)
+	})
+}
 
-		// fmt.Println("Processing item:", item.Node.Name, "Depth:", item.Depth)
+// Recipes of one element that pass the tier and no recipe rules
+type bfsCandidates struct {
+	recipes        [][]*search.ElementNode
+	prunedTier     int
+	prunedNoRecipe int
+}
 
-		result.iteration++
-		result.visitedNodes++
+// Sorts the recipes of node with recipePruneReason, like every other algorithm
+func usableRecipes(node *search.ElementNode) bfsCandidates {
+	var candidates bfsCandidates
+	if isBaseElement(node) || isNoRecipe(node) {
+		return candidates
+	}
 
-		if isBaseElement(item.Node) {
-			continue
+	for _, recipe := range node.Recipes {
+		switch recipePruneReason(node, recipe) {
+		case PruneNoRecipe:
+			candidates.prunedNoRecipe++
+		case PruneTier:
+			candidates.prunedTier++
+		default:
+			candidates.recipes = append(candidates.recipes, recipe)
 		}
-		if isNoRecipe(item.Node) {
+	}
+	return candidates
+}
+
+// Adds the candidate recipes of item whose combination is not in used yet on
+// its ancestry, marks them used and queues their ingredients
+func addRecipes(used usedCombinations, item QueueItem, candidates bfsCandidates, result *BFSProgressResult, queue func(QueueItem)) {
+	result.iteration++
+	result.visitedNodes++
+	result.prunedTier += candidates.prunedTier
+	result.prunedNoRecipe += candidates.prunedNoRecipe
+
+	for _, recipe := range candidates.recipes {
+		if isElemCombUsed(used, item.Node.Name, recipe[0].ID, recipe[1].ID, item.AncestryChain) {
+			result.prunedDedup++
 			continue
 		}
+		markElemCombUsed(used, item.Node.Name, recipe[0].ID, recipe[1].ID, item.AncestryChain)
+		result.recipesTried++
+
+		result.recipes = append(result.recipes, JSONRecipe{
+			Ingredients: []string{recipe[0].Name, recipe[1].Name},
+			Result:      item.Node.Name,
+			Step:        item.Depth,
+		})
+
+		for _, ingredient := range recipe {
+			result.nodes = append(result.nodes, JSONNode{
+				ID:   ingredient.ID,
+				Name: ingredient.Name,
+			})
 
-		for _, recipe := range item.Node.Recipes {
-			if len(recipe) != 2 || recipe[0] == nil || recipe[1] == nil {
-				continue
-			}
-
-			if (isNoRecipe(recipe[0]) && !isBaseElement(recipe[0])) || (isNoRecipe(recipe[1]) && !isBaseElement(recipe[1])) {
-				continue
-			}
-			if recipe[0].Tier >= item.Node.Tier || recipe[1].Tier >= item.Node.Tier {
-				continue
-			}
-
-			usedElemCombMutex.Lock()
-			if isElemCombUsed(item.Node.Name, recipe[0].ID, recipe[1].ID, item.AncestryChain) {
-				usedElemCombMutex.Unlock()
+			if isBaseElement(ingredient) {
+				result.visitedNodes++
 				continue
 			}
-			markElemCombUsed(item.Node.Name, recipe[0].ID, recipe[1].ID, item.AncestryChain)
-			usedElemCombMutex.Unlock()
-
-			result.recipes = append(result.recipes, JSONRecipe{
-				Ingredients: []string{recipe[0].Name, recipe[1].Name},
-				Result:      item.Node.Name,
-				Step:        item.Depth,
-			})
-
-			for _, ingredient := range recipe {
-				if ingredient == nil {
-					continue
-				}
-
-				result.nodes = append(result.nodes, JSONNode{
-					ID:   ingredient.ID,
-					Name: ingredient.Name,
-				})
-
-				newAncestry := &AncestryChain{
+			queue(QueueItem{
+				Node: ingredient,
+				AncestryChain: &AncestryChain{
 					Element: ingredient.Name,
 					Parents: item.AncestryChain,
-				}
-
-				if !isBaseElement(ingredient) {
-					next <- QueueItem{
-						Node:          ingredient,
-						AncestryChain: newAncestry,
-						Depth:         item.Depth + 1,
-					}
-				} else {
-					result.visitedNodes++
-				}
-
-			}
-
+				},
+				Depth: item.Depth + 1,
+			})
 		}
 	}
 }
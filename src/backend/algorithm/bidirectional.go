@@ -0,0 +1,230 @@
+package algorithm
+
+import (
+	"backend/search"
+	"context"
+	"slices"
+)
+
+// Nodes visited by each side of the bidirectional search
+type BidirectionalVisits struct {
+	Forward  int `json:"forward"`  // Expanded from the base elements through ElementNode.Children
+	Backward int `json:"backward"` // Expanded from the target through ElementNode.Recipes
+}
+
+type bidirectionalSearch struct {
+	graph    *search.RecipeGraph
+	maxPaths int
+	dedup    bool
+
+	// Forward side. Every element here has a known tree made of base elements
+	forward         map[int]*TreeNode
+	forwardFrontier []*search.ElementNode
+	forwardSeen     map[int]bool // Elements counted in visits, reached or not
+
+	// Backward side. Every element here had its recipes expanded
+	backward         map[int]bool
+	backwardFrontier []*search.ElementNode
+
+	visits *BidirectionalVisits
+	stats  *StatsCollector
+}
+
+// Searches forward from the base elements and backward from target at the
+// same time, always expanding the smaller frontier, until the explored halves
+// can be stitched into maxPaths recipe trees or neither side can grow anymore
+func Bidirectional(ctx context.Context, target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, dedup bool, visits *BidirectionalVisits, stats *StatsCollector) []*RecipeTree {
+	key := CacheKey{Algorithm: "bidirectional", Element: target.ID, MaxPaths: maxPaths, Dedup: dedup}
+	cache := resultCacheFor(ctx)
+	if entry, ok := cache.get(graph, key); ok {
+		*visits = entry.visits
+		stats.addDuplicates(entry.duplicates)
+		stats.markCached()
+		return treesFromRoots(entry.roots)
+	}
+
+	duplicates := stats.duplicateCount()
+	roots := rootsFromTrees(bidirectional(ctx, target, graph, maxPaths, dedup, visits, stats))
+	if ctx.Err() != nil {
+		return treesFromRoots(roots)
+	}
+	cache.put(graph, &cacheEntry{key: key, roots: roots, nodeVisited: visits.Forward + visits.Backward,
+		duplicates: stats.duplicateCount() - duplicates, visits: *visits})
+	return treesFromRoots(roots)
+}
+
+func bidirectional(ctx context.Context, target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, dedup bool, visits *BidirectionalVisits, stats *StatsCollector) []*RecipeTree {
+	s := &bidirectionalSearch{
+		graph:            graph,
+		maxPaths:         maxPaths,
+		dedup:            dedup,
+		forward:          make(map[int]*TreeNode),
+		forwardFrontier:  make([]*search.ElementNode, 0, len(graph.BaseElements)),
+		forwardSeen:      make(map[int]bool),
+		backward:         make(map[int]bool),
+		backwardFrontier: []*search.ElementNode{target},
+		visits:           visits,
+		stats:            stats,
+	}
+	for _, base := range graph.BaseElements {
+		s.forward[base.ID] = NewLeaf(base)
+		s.forwardFrontier = append(s.forwardFrontier, base)
+		s.forwardSeen[base.ID] = true
+		visits.Forward++
+	}
+	s.backward[target.ID] = true
+
+	for ctx.Err() == nil {
+		// Expanding further can only give more trees
+		roots, duplicates := s.stitch(target)
+		exhausted := len(s.forwardFrontier) == 0 && len(s.backwardFrontier) == 0
+		if (maxPaths > 0 && len(roots) >= maxPaths) || exhausted {
+			stats.addDuplicates(duplicates)
+			trees := make([]*RecipeTree, 0, len(roots))
+			for _, root := range roots {
+				trees = append(trees, NewRecipeTree(root))
+			}
+			return trees
+		}
+
+		if len(s.backwardFrontier) == 0 || (len(s.forwardFrontier) > 0 && len(s.forwardFrontier) <= len(s.backwardFrontier)) {
+			s.expandForward()
+		} else {
+			s.expandBackward()
+		}
+	}
+	return []*RecipeTree{}
+}
+
+// Crafts every child of the forward frontier that has a usable recipe made
+// of elements already reached from the base elements
+func (s *bidirectionalSearch) expandForward() {
+	next := make([]*search.ElementNode, 0)
+	for _, node := range s.forwardFrontier {
+		for _, child := range node.Children {
+			if _, ok := s.forward[child.ID]; ok {
+				continue
+			}
+			// A child without a tree yet is checked again from its other
+			// ingredients, but only visited once
+			if !s.forwardSeen[child.ID] {
+				s.forwardSeen[child.ID] = true
+				s.visits.Forward++
+			}
+
+			for i, recipe := range child.Recipes {
+				if !s.stats.checkRecipe(child, recipe) {
+					continue
+				}
+				ingredient0, ok0 := s.forward[recipe[0].ID]
+				ingredient1, ok1 := s.forward[recipe[1].ID]
+				if ok0 && ok1 {
+					s.forward[child.ID] = NewCraft(child, i, ingredient0, ingredient1)
+					next = append(next, child)
+					break
+				}
+			}
+		}
+	}
+	s.forwardFrontier = next
+}
+
+// Adds the ingredients of every usable recipe of the backward frontier
+func (s *bidirectionalSearch) expandBackward() {
+	next := make([]*search.ElementNode, 0)
+	for _, node := range s.backwardFrontier {
+		s.visits.Backward++
+		if isBaseElement(node) {
+			continue
+		}
+
+		for _, recipe := range node.Recipes {
+			if !s.stats.checkRecipe(node, recipe) {
+				continue
+			}
+			for _, ingredient := range recipe {
+				if s.backward[ingredient.ID] || slices.Contains(next, ingredient) {
+					continue
+				}
+				next = append(next, ingredient)
+			}
+		}
+	}
+	for _, node := range next {
+		s.backward[node.ID] = true
+	}
+	s.backwardFrontier = next
+}
+
+// Trees of one element while stitching, keyed by their canonical form with
+// dedup and by their exact form otherwise. Splitting an element builds its
+// forward tree again, which is neither kept twice nor a duplicate
+type stitchedTrees struct {
+	dedup bool
+	exact map[string]string // Key to the exact form of the tree kept for it
+	trees []*TreeNode
+}
+
+// Adds tree unless an equal one is kept. duplicate tells whether it was
+// skipped for a tree made of other recipes
+func (t *stitchedTrees) add(tree *TreeNode) (added, duplicate bool) {
+	exact := exactForm(tree)
+	key := exact
+	if t.dedup {
+		key = canonicalForm(tree)
+	}
+	if kept, ok := t.exact[key]; ok {
+		return false, kept != exact
+	}
+	t.exact[key] = exact
+	t.trees = append(t.trees, tree)
+	return true, false
+}
+
+// Builds trees for target where every element that is still on the backward
+// frontier is replaced by the tree found from the forward side. Also returns
+// the trees of target skipped by dedup
+func (s *bidirectionalSearch) stitch(target *search.ElementNode) ([]*TreeNode, int) {
+	mem := make(map[int][]*TreeNode)
+	duplicates := 0
+
+	var build func(*search.ElementNode) []*TreeNode
+	build = func(node *search.ElementNode) []*TreeNode {
+		if cached, ok := mem[node.ID]; ok {
+			return cached
+		}
+
+		kept := &stitchedTrees{dedup: s.dedup, exact: make(map[string]string), trees: make([]*TreeNode, 0)}
+		if tree, ok := s.forward[node.ID]; ok {
+			kept.add(tree)
+		}
+		// Only elements whose recipes were expanded can be split further
+		if !s.backward[node.ID] || slices.Contains(s.backwardFrontier, node) || isBaseElement(node) {
+			mem[node.ID] = kept.trees
+			return kept.trees
+		}
+
+		for i, recipe := range node.Recipes {
+			if s.maxPaths > 0 && len(kept.trees) >= s.maxPaths {
+				break
+			}
+			if !IsUsableRecipe(node, recipe) {
+				continue
+			}
+			for _, left := range build(recipe[0]) {
+				for _, right := range build(recipe[1]) {
+					if s.maxPaths > 0 && len(kept.trees) >= s.maxPaths {
+						break
+					}
+					if _, duplicate := kept.add(NewCraft(node, i, left, right)); duplicate && node == target {
+						duplicates++
+					}
+				}
+			}
+		}
+		mem[node.ID] = kept.trees
+		return kept.trees
+	}
+
+	return build(target), duplicates
+}
@@ -0,0 +1,229 @@
+package algorithm
+
+import (
+	"backend/search"
+	"container/list"
+	"context"
+	"sync"
+)
+
+const (
+	defaultCacheSize        = 1024
+	defaultElementCacheSize = 4096
+)
+
+// Identifies a cached search result. The subtrees of single elements found by
+// DFS and IDDFS use the same format in their own cache, see elementCache
+type CacheKey struct {
+	Algorithm string
+	Element   int // Element ID
+	MaxPaths  int
+	MaxDepth  int
+	Dedup     bool
+}
+
+type cacheEntry struct {
+	key         CacheKey
+	roots       []*TreeNode // Never modified once cached, trees are copied out by NewRecipeTree
+	nodeVisited int
+	duplicates  int                 // Trees of the target skipped by Dedup
+	depth       int                 // IDDFS only
+	visits      BidirectionalVisits // Bidirectional only
+}
+
+type CacheStatistic struct {
+	Size     int `json:"size"`
+	Capacity int `json:"capacity"`
+	Hits     int `json:"hits"`
+	Misses   int `json:"misses"`
+}
+
+// LRU cache of recipe trees shared by every request. All entries belong to
+// one graph and are dropped as soon as the cache is used with another one,
+// so reloading the graph always invalidates it
+type SubtreeCache struct {
+	mu       sync.Mutex
+	graph    *search.RecipeGraph
+	capacity int
+	entries  map[CacheKey]*list.Element
+	order    *list.List // Most recently used first
+	hits     int
+	misses   int
+}
+
+var subtreeCache = NewSubtreeCache(defaultCacheSize)
+
+// Subtrees of the elements met while searching, found again by any search
+// that needs the same ingredient. They have their own budget, so they never
+// evict the results of whole requests
+var elementCache = NewSubtreeCache(defaultElementCacheSize)
+
+type privateCacheKey struct{}
+
+// Lets the search running with ctx skip the shared caches, see
+// SearchOptions.NoCache. It still gets an element cache of its own, so
+// it does not find the same subtrees over and over
+func withPrivateCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, privateCacheKey{}, NewSubtreeCache(elementCache.statistic().Capacity))
+}
+
+// The cache for the results of whole searches, nil if ctx must not use it
+func resultCacheFor(ctx context.Context) *SubtreeCache {
+	if _, private := ctx.Value(privateCacheKey{}).(*SubtreeCache); private {
+		return nil
+	}
+	return subtreeCache
+}
+
+// The cache for element subtrees, private to the search if ctx asks for it
+func elementCacheFor(ctx context.Context) *SubtreeCache {
+	if cache, private := ctx.Value(privateCacheKey{}).(*SubtreeCache); private {
+		return cache
+	}
+	return elementCache
+}
+
+func NewSubtreeCache(capacity int) *SubtreeCache {
+	return &SubtreeCache{
+		capacity: capacity,
+		entries:  make(map[CacheKey]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Must be called with the lock held
+func (c *SubtreeCache) useGraph(graph *search.RecipeGraph) {
+	if c.graph != graph {
+		c.graph = graph
+		c.entries = make(map[CacheKey]*list.Element)
+		c.order.Init()
+	}
+}
+
+// A nil cache never has the key
+func (c *SubtreeCache) get(graph *search.RecipeGraph, key CacheKey) (*cacheEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.useGraph(graph)
+	item, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(item)
+	return item.Value.(*cacheEntry), true
+}
+
+// A nil cache drops the entry
+func (c *SubtreeCache) put(graph *search.RecipeGraph, entry *cacheEntry) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.useGraph(graph)
+	if c.capacity <= 0 {
+		return
+	}
+	if item, ok := c.entries[entry.key]; ok {
+		item.Value = entry
+		c.order.MoveToFront(item)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *SubtreeCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.graph = nil
+	c.entries = make(map[CacheKey]*list.Element)
+	c.order.Init()
+}
+
+func (c *SubtreeCache) resize(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.capacity = capacity
+	for c.order.Len() > max(c.capacity, 0) {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *SubtreeCache) statistic() CacheStatistic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStatistic{
+		Size:     c.order.Len(),
+		Capacity: c.capacity,
+		Hits:     c.hits,
+		Misses:   c.misses,
+	}
+}
+
+// Drops every cached tree. Call whenever the recipe graph is reloaded
+func InvalidateCache() {
+	subtreeCache.invalidate()
+	elementCache.invalidate()
+}
+
+// Sets the maximum number of cached search results. 0 disables the cache
+func SetCacheSize(capacity int) { subtreeCache.resize(capacity) }
+
+// Sets the maximum number of cached element subtrees. 0 disables the cache
+func SetElementCacheSize(capacity int) { elementCache.resize(capacity) }
+
+func GetCacheStatistic() CacheStatistic { return subtreeCache.statistic() }
+
+func treesFromRoots(roots []*TreeNode) []*RecipeTree {
+	trees := make([]*RecipeTree, 0, len(roots))
+	for _, root := range roots {
+		trees = append(trees, NewRecipeTree(root))
+	}
+	return trees
+}
+
+func rootsFromTrees(trees []*RecipeTree) []*TreeNode {
+	roots := make([]*TreeNode, 0, len(trees))
+	for _, tree := range trees {
+		roots = append(roots, tree.Root)
+	}
+	return roots
+}
+
+// Returns the cached trees for key, or runs the search and caches its trees
+// together with the number of nodes it visited. A search stopped by ctx is
+// not cached, it may have missed trees
+func cachedSearch(ctx context.Context, graph *search.RecipeGraph, key CacheKey, nodeVisited *int, stats *StatsCollector, run func() []*RecipeTree) []*RecipeTree {
+	cache := resultCacheFor(ctx)
+	if entry, ok := cache.get(graph, key); ok {
+		*nodeVisited = entry.nodeVisited
+		stats.addDuplicates(entry.duplicates)
+		stats.markCached()
+		return treesFromRoots(entry.roots)
+	}
+
+	duplicates := stats.duplicateCount()
+	roots := rootsFromTrees(run())
+	if ctx.Err() != nil {
+		return treesFromRoots(roots)
+	}
+	cache.put(graph, &cacheEntry{key: key, roots: roots, nodeVisited: *nodeVisited, duplicates: stats.duplicateCount() - duplicates})
+	return treesFromRoots(roots)
+}
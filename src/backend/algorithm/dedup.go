@@ -0,0 +1,71 @@
+package algorithm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Canonical form of a subtree. Ingredients are sorted by their own canonical
+// form, so swapping the two ingredients of any recipe, or listing sibling
+// subtrees in another order, gives the same string
+func canonicalForm(node *TreeNode) string {
+	if len(node.Ingredients) == 0 {
+		return strconv.Itoa(node.ID)
+	}
+
+	forms := make([]string, 0, len(node.Ingredients))
+	for _, ingredient := range node.Ingredients {
+		forms = append(forms, canonicalForm(ingredient))
+	}
+	slices.Sort(forms)
+	return strconv.Itoa(node.ID) + "(" + strings.Join(forms, ",") + ")"
+}
+
+// Form of a subtree that keeps the recipe indexes and the order of the
+// ingredients, equal only for trees of the same recipes
+func exactForm(node *TreeNode) string {
+	if len(node.Ingredients) == 0 {
+		return strconv.Itoa(node.ID)
+	}
+
+	forms := make([]string, 0, len(node.Ingredients))
+	for _, ingredient := range node.Ingredients {
+		forms = append(forms, exactForm(ingredient))
+	}
+	return strconv.Itoa(node.ID) + "." + strconv.Itoa(node.Recipe) + "(" + strings.Join(forms, ",") + ")"
+}
+
+// Hash of the structure of a recipe tree. Two trees have the same hash
+// exactly when they only differ in ingredient or sibling order
+func CanonicalHash(tree *RecipeTree) string {
+	sum := sha256.Sum256([]byte(canonicalForm(tree.Root)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Canonical forms of the trees an enumeration already has. Structurally equal
+// trees are skipped as soon as they are built, so they never count towards
+// maxPaths. A nil set keeps every tree
+type canonicalSet map[string]bool
+
+func newCanonicalSet(dedup bool) canonicalSet {
+	if !dedup {
+		return nil
+	}
+	return make(canonicalSet)
+}
+
+// Adds node unless the set already has a tree of the same structure
+func (s canonicalSet) add(node *TreeNode) bool {
+	if s == nil {
+		return true
+	}
+	form := canonicalForm(node)
+	if s[form] {
+		return false
+	}
+	s[form] = true
+	return true
+}
@@ -2,27 +2,11 @@ package algorithm
 
 import (
 	"backend/search"
-	"fmt"
+	"context"
 	"slices"
 	"sync"
 )
 
-type GraphJSONNode struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-}
-
-type GraphJSONRecipe struct {
-	ID      int   `json:"id"`
-	Element int   `json:"element"`
-	Recipe  []int `json:"recipe"`
-}
-
-type DFSGraphJSONWithRecipes struct {
-	Nodes   []GraphJSONNode   `json:"nodes"`
-	Recipes []GraphJSONRecipe `json:"recipes"`
-}
-
 type ResultTree struct {
 	mu   sync.Mutex
 	path []*Recipe
@@ -33,17 +17,20 @@ type Recipe struct {
 	composition []*Recipe
 }
 
-type PathResult map[string]RecipeJSON
-
-func DFS(target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, nodeVisited *int) []PathResult {
+func DFS(ctx context.Context, target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, dedup bool, nodeVisited *int, stats *StatsCollector) []*RecipeTree {
 	if maxPaths == 1 {
-		result := &ResultTree{path: make([]*Recipe, 0)}
-		findSinglePath(target, graph, result, nodeVisited)
+		root := findSinglePath(ctx, target, graph, nodeVisited, stats)
+		if root == nil {
+			return []*RecipeTree{}
+		}
 
-		return []PathResult{ParseCraftingPathToJSON(result, graph)}
+		return []*RecipeTree{NewRecipeTree(root)}
 	}
 
-	return findMultiplePaths(target, graph, maxPaths, nodeVisited)
+	key := CacheKey{Algorithm: "dfs", Element: target.ID, MaxPaths: maxPaths, Dedup: dedup}
+	return cachedSearch(ctx, graph, key, nodeVisited, stats, func() []*RecipeTree {
+		return findMultiplePaths(ctx, target, graph, maxPaths, dedup, nodeVisited, nil, stats)
+	})
 }
 
 func mergeTree(tree0 *ResultTree, tree1 *ResultTree, resulto *ResultTree) {
@@ -53,47 +40,66 @@ func mergeTree(tree0 *ResultTree, tree1 *ResultTree, resulto *ResultTree) {
 
 /* ----------------------------------------- Single Recipe DFS ----------------------------------------------- */
 
-func findSinglePath(target *search.ElementNode, graph *search.RecipeGraph, result *ResultTree, nodeVisited *int) *Recipe {
+// The subtree found for every element, including the ones without any, is
+// cached so shared ingredients are only searched once across all requests. A
+// cached subtree still counts the nodes its search visited, so nodeVisited
+// does not depend on what is cached. Once ctx is done nothing is found and
+// nothing is cached anymore
+func findSinglePath(ctx context.Context, target *search.ElementNode, graph *search.RecipeGraph, nodeVisited *int, stats *StatsCollector) *TreeNode {
+	if ctx.Err() != nil {
+		return nil
+	}
+	visitedBefore := *nodeVisited
 	*nodeVisited++
 
 	if slices.Contains(graph.BaseElements, target) {
-		*result = ResultTree{path: make([]*Recipe, 0)}
-		baseElem := &Recipe{element: target}
-		baseElem.composition = []*Recipe{baseElem, baseElem}
-		result.path = append(result.path, baseElem)
-		return baseElem
+		return NewLeaf(target)
 	}
 	if target.Name == "Time" {
 		return nil
 	}
 
+	key := CacheKey{Algorithm: "dfs", Element: target.ID, MaxPaths: 1}
+	if entry, ok := elementCacheFor(ctx).get(graph, key); ok {
+		*nodeVisited = visitedBefore + entry.nodeVisited
+		stats.markCached()
+		if len(entry.roots) == 0 {
+			return nil
+		}
+		return entry.roots[0]
+	}
+
 	// Try each recipe
-	for _, recipe := range target.Recipes {
+	var found *TreeNode
+	for i, recipe := range target.Recipes {
 		if recipe[0].Tier >= target.Tier || recipe[1].Tier >= target.Tier {
+			stats.prune(PruneTier)
 			continue
 		}
+		stats.tryRecipe()
 
-		result0 := &ResultTree{path: make([]*Recipe, 0)}
-		component0 := findSinglePath(recipe[0], graph, result0, nodeVisited)
+		component0 := findSinglePath(ctx, recipe[0], graph, nodeVisited, stats)
 		if component0 == nil {
 			continue
 		}
-		result1 := &ResultTree{path: make([]*Recipe, 0)}
-		component1 := findSinglePath(recipe[1], graph, result1, nodeVisited)
+		component1 := findSinglePath(ctx, recipe[1], graph, nodeVisited, stats)
 		if component1 == nil {
 			continue
 		}
 
-		mergeTree(result0, result1, result)
-		validRecipe := &Recipe{
-			element:     target,
-			composition: []*Recipe{component0, component1},
-		}
-		result.path = append(result.path, validRecipe)
-		return validRecipe
+		found = NewCraft(target, i, component0, component1)
+		break
+	}
+	if ctx.Err() != nil {
+		return nil
 	}
 
-	return nil
+	entry := &cacheEntry{key: key, roots: []*TreeNode{}, nodeVisited: *nodeVisited - visitedBefore}
+	if found != nil {
+		entry.roots = append(entry.roots, found)
+	}
+	elementCacheFor(ctx).put(graph, entry)
+	return found
 }
 
 /* ----------------------------------------- Multiple Recipe DFS ----------------------------------------------- */
@@ -107,10 +113,15 @@ type SearchStatistic struct {
 	mu             sync.Mutex
 	remainingPaths int
 	nodeVisited    int
+	ctx            context.Context // Every findPath gives up once it is done
+	observer       *SearchObserver
+	collector      *StatsCollector
 }
 
-func findMultiplePaths(target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, nodeVisited *int) []PathResult {
-	resultJSONs := make([]PathResult, 0, maxPaths)
+// With dedup, trees of the same structure as an earlier one are skipped and
+// the search goes on until it has maxPaths distinct trees
+func findMultiplePaths(ctx context.Context, target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, dedup bool, nodeVisited *int, observer *SearchObserver, collector *StatsCollector) []*RecipeTree {
+	trees := make([]*RecipeTree, 0, maxPaths)
 
 	status := SearchStatus{
 		result:         make(chan int),
@@ -120,21 +131,36 @@ func findMultiplePaths(target *search.ElementNode, graph *search.RecipeGraph, ma
 		mu:             sync.Mutex{},
 		remainingPaths: maxPaths,
 		nodeVisited:    0,
+		ctx:            ctx,
+		observer:       observer,
+		collector:      collector,
 	}
 	result := &ResultTree{path: make([]*Recipe, 0)}
 
-	go findPath(target, graph, result, status, stats)
+	goFindPath(target, graph, result, status, stats)
 
+	kept := newCanonicalSet(dedup)
 	counter := 0
 	condition := <-status.result
 	for condition != 0 {
+		// The root recipe is always the first in the path
+		tree := NewRecipeTree(treeFromRecipe(result.path[0], graph))
+		if !kept.add(tree.Root) {
+			collector.addDuplicates(1)
+			status.continueSignal <- 1
+			condition = <-status.result
+			continue
+		}
 		counter++
+		trees = append(trees, tree)
+		if observer != nil {
+			stats.mu.Lock()
+			visited := stats.nodeVisited
+			stats.mu.Unlock()
+			observer.emit(SearchEvent{Type: EventTreeFound, Element: target.Name, Tree: tree, VisitedNodes: visited})
+		}
 
-		// Convert result to JSON instead of writing to file
-		pathJSON := ParseCraftingPathToJSON(result, graph)
-		resultJSONs = append(resultJSONs, pathJSON)
-
-		if counter >= maxPaths {
+		if counter >= maxPaths || ctx.Err() != nil {
 			status.continueSignal <- 0
 			<-status.result
 			break
@@ -147,13 +173,28 @@ func findMultiplePaths(target *search.ElementNode, graph *search.RecipeGraph, ma
 	stats.mu.Lock()
 	*nodeVisited = stats.nodeVisited
 	stats.mu.Unlock()
-	return resultJSONs
+	return trees
+}
+
+// Runs findPath in a new goroutine counted by the collector
+func goFindPath(target *search.ElementNode, graph *search.RecipeGraph, result *ResultTree, status SearchStatus, stats *SearchStatistic) {
+	stats.collector.goroutinesStarted(1)
+	go func() {
+		defer stats.collector.goroutinesDone(1)
+		findPath(target, graph, result, status, stats)
+	}()
 }
 
 func findPath(target *search.ElementNode, graph *search.RecipeGraph, result *ResultTree, status SearchStatus, stats *SearchStatistic) {
 	stats.mu.Lock()
 	stats.nodeVisited++
+	visited := stats.nodeVisited
 	stats.mu.Unlock()
+	stats.observer.emit(SearchEvent{Type: EventNodeVisited, Element: target.Name, VisitedNodes: visited})
+	if stats.ctx.Err() != nil {
+		status.result <- 0
+		return
+	}
 
 	// Base case: if the target is a base element, return
 	if slices.Contains(graph.BaseElements, target) {
@@ -174,228 +215,86 @@ func findPath(target *search.ElementNode, graph *search.RecipeGraph, result *Res
 	}
 
 	for _, recipe := range target.Recipes {
+		if stats.ctx.Err() != nil {
+			break
+		}
 		if recipe[0].Tier >= target.Tier || recipe[1].Tier >= target.Tier {
+			stats.collector.prune(PruneTier)
 			continue
 		}
+		stats.collector.tryRecipe()
+		stats.observer.emit(SearchEvent{
+			Type:         EventRecipeTried,
+			Element:      target.Name,
+			Ingredients:  []string{recipe[0].Name, recipe[1].Name},
+			VisitedNodes: visited,
+		})
 
 		status0 := SearchStatus{result: make(chan int), continueSignal: make(chan int)}
 		result0 := &ResultTree{path: make([]*Recipe, 0)}
-		go findPath(recipe[0], graph, result0, status0, stats)
+		goFindPath(recipe[0], graph, result0, status0, stats)
 
 		status1 := SearchStatus{result: make(chan int), continueSignal: make(chan int)}
 		result1 := &ResultTree{path: make([]*Recipe, 0)}
-		go findPath(recipe[1], graph, result1, status1, stats)
+		goFindPath(recipe[1], graph, result1, status1, stats)
 
+		// Every left tree is combined with the current right tree, then the
+		// right tree moves on and the left trees start over
 		condition0 := <-status0.result
 		condition1 := <-status1.result
 		for condition0 != 0 && condition1 != 0 {
-			if condition0 == 1 && condition1 == 1 {
-				recipe := &Recipe{
-					element:     target,
-					composition: []*Recipe{result0.path[0], result1.path[0]},
-				}
-				result.mu.Lock()
-				result.path = make([]*Recipe, 0)
-				result.path = append(result.path, recipe)
-				mergeTree(result0, result1, result)
-				result.mu.Unlock()
+			combined := &Recipe{
+				element:     target,
+				composition: []*Recipe{result0.path[0], result1.path[0]},
+			}
+			result.mu.Lock()
+			result.path = make([]*Recipe, 0)
+			result.path = append(result.path, combined)
+			mergeTree(result0, result1, result)
+			result.mu.Unlock()
 
-				status.result <- 1
-				continueSearch := <-status.continueSignal
+			status.result <- 1
+			continueSearch := <-status.continueSignal
 
-				if continueSearch == 0 {
-					status0.continueSignal <- 0
-					status1.continueSignal <- 0
-					<-status0.result
-					<-status1.result
+			if continueSearch == 0 {
+				status0.continueSignal <- 0
+				status1.continueSignal <- 0
+				<-status0.result
+				<-status1.result
 
+				// Stop here instead of trying the next recipes, the caller
+				// only waits for this last result
+				status.result <- 0
+				return
+			}
+
+			status0.continueSignal <- 1
+			condition0 = <-status0.result
+			if condition0 == 0 {
+				status1.continueSignal <- 1
+				condition1 = <-status1.result
+				if condition1 == 0 {
 					break
-				} else {
-					status0.continueSignal <- 1
-					condition0 = <-status0.result
 				}
-			} else if condition0 == 0 {
-				status1.continueSignal <- 0
 
 				status0 = SearchStatus{result: make(chan int), continueSignal: make(chan int)}
 				result0 = &ResultTree{path: make([]*Recipe, 0)}
-				go findPath(recipe[0], graph, result0, status0, stats)
-
+				goFindPath(recipe[0], graph, result0, status0, stats)
 				condition0 = <-status0.result
-				condition1 = <-status1.result
-			}
-		}
-	}
-
-	status.result <- 0
-	// Kill this routine
-}
-
-/* ----------------------------------------- Parse Search Output ----------------------------------------------- */
-
-type NodeJSON struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-}
-
-type RecipeJSON struct {
-	Element string   `json:"element"`
-	Recipe  []string `json:"recipe"`
-}
-
-type ResultJSON struct {
-	Recipes []RecipeJSON
-}
-
-// Fungsi baru yang hanya mengembalikan PathResult tanpa menyimpan ke file
-func ParseCraftingPathToJSON(result *ResultTree, graph *search.RecipeGraph) PathResult {
-	// ResultTree is locked in the caller side
-	recipeToID := make(map[*Recipe]int)
-	for i, recipe := range result.path {
-		recipeToID[recipe] = i
-	}
-
-	pathJSON := make(PathResult)
-	for _, recipe := range result.path {
-		if slices.Contains(graph.BaseElements, recipe.element) {
-			pathJSON[fmt.Sprintf("%d", recipeToID[recipe])] = RecipeJSON{
-				Element: recipe.element.Name,
-				Recipe:  []string{},
 			}
-			continue
-		}
-
-		pathJSON[fmt.Sprintf("%d", recipeToID[recipe])] = RecipeJSON{
-			Element: recipe.element.Name,
-			Recipe:  make([]string, len(recipe.composition)),
 		}
-		for i, comp := range recipe.composition {
-			pathJSON[fmt.Sprintf("%d", recipeToID[recipe])].Recipe[i] = fmt.Sprintf("%d", recipeToID[comp])
-		}
-	}
-
-	return pathJSON
-}
-
-// Fungsi lama yang menyimpan ke file, tetap dipertahankan untuk kompatibilitas
-func ParseCraftingPathToGraphJSON(result *ResultTree, graph *search.RecipeGraph) DFSGraphJSONWithRecipes {
-	// Map untuk ngasih ID unik ke tiap Recipe
-	recipeToID := make(map[*Recipe]int)
-	for i, recipe := range result.path {
-		recipeToID[recipe] = i
-	}
-
-	nodes := make([]GraphJSONNode, 0, len(result.path))
-	recipes := make([]GraphJSONRecipe, 0, len(result.path))
 
-	for _, recipe := range result.path {
-		id := recipeToID[recipe]
-		nodes = append(nodes, GraphJSONNode{
-			ID:   id,
-			Name: recipe.element.Name,
-		})
-
-		if slices.Contains(graph.BaseElements, recipe.element) {
-			// Base element gak ada resep
-			recipes = append(recipes, GraphJSONRecipe{
-				ID:      id,
-				Element: id,
-				Recipe:  []int{},
-			})
-			continue
+		// One side ran out of trees, the other one still waits
+		if condition0 != 0 {
+			status0.continueSignal <- 0
+			<-status0.result
 		}
-
-		// Non-base element, ada komponennya
-		compIDs := make([]int, len(recipe.composition))
-		for i, comp := range recipe.composition {
-			compIDs[i] = recipeToID[comp]
+		if condition1 != 0 {
+			status1.continueSignal <- 0
+			<-status1.result
 		}
-
-		recipes = append(recipes, GraphJSONRecipe{
-			ID:      id,
-			Element: id,
-			Recipe:  compIDs,
-		})
 	}
 
-	return DFSGraphJSONWithRecipes{
-		Nodes:   nodes,
-		Recipes: recipes,
-	}
+	status.result <- 0
+	// Kill this routine
 }
-
-// func main() {
-// 	err := scraping.ScrapeRecipes(false)
-// 	if err != nil {
-// 		log.Fatal("Error while scraping recipes:", err)
-// 	}
-
-// 	recipes, err := scraping.GetScrapedRecipesJSON()
-// 	if err != nil {
-// 		log.Fatal("Error loading recipes from JSON:", err)
-// 	}
-
-// 	var graph search.RecipeGraph
-// 	err = search.ConstructRecipeGraph(recipes, &graph)
-// 	if err != nil {
-// 		log.Fatal("Error constructing recipe graph:", err)
-// 	}
-
-// 	reader := bufio.NewReader(os.Stdin)
-
-// 	fmt.Print("Enter target element name: ")
-// 	targetName, _ := reader.ReadString('\n')
-// 	targetName = strings.TrimSpace(targetName)
-
-// 	target, err := search.GetElementByName(&graph, targetName)
-// 	if err != nil {
-// 		log.Fatalf("Error: element '%s' not found.\n", targetName)
-// 	}
-
-// 	fmt.Print("Enter number of paths to find: ")
-// 	inputMax, _ := reader.ReadString('\n')
-// 	inputMax = strings.TrimSpace(inputMax)
-// 	maxPaths, err := strconv.Atoi(inputMax)
-// 	if err != nil || maxPaths <= 0 {
-// 		log.Fatalf("Invalid number: %v\n", inputMax)
-// 	}
-
-// 	nodeVisited := 0
-
-// 	//startTime := time.Now()
-// 	// Panggil DFS yang mengembalikan data JSON
-// 	resultData := DFS(target, &graph, maxPaths, &nodeVisited)
-// 	//elapsedTime := time.Since(startTime)
-
-// 	// Tampilkan hasil
-// 	//fmt.Printf("\nFound %d paths in %.2f seconds.\n", len(resultData), elapsedTime.Seconds())
-// 	fmt.Printf("Visited %d nodes during search.\n", nodeVisited)
-
-// 	// Simpan hasil ke file
-// 	fmt.Println("\nSaving results to files:")
-// 	for i, pathJSON := range resultData {
-// 		resultFile := "result_" + fmt.Sprintf("%03d", i+1) + ".json"
-
-// 		jsonData, err := json.MarshalIndent(pathJSON, "", " ")
-// 		if err != nil {
-// 			fmt.Printf("Error encoding JSON for path %d: %v\n", i+1, err)
-// 			continue
-// 		}
-
-// 		err = os.WriteFile(resultFile, jsonData, 0644)
-// 		if err != nil {
-// 			fmt.Printf("Error writing path %d to file: %v\n", i+1, err)
-// 			continue
-// 		}
-
-// 		fmt.Println("- " + resultFile)
-// 	}
-
-// 	// Optional: Cetak konten file
-// 	for i, pathJSON := range resultData {
-// 		fmt.Printf("\nContents of result_%03d.json:\n", i+1)
-// 		for id, rec := range pathJSON {
-// 			fmt.Printf("- Node %s: %+v\n", id, rec)
-// 		}
-// 	}
-// }
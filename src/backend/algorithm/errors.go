@@ -0,0 +1,23 @@
+package algorithm
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown algorithm")
+	ErrBaseElement      = errors.New("base elements have no recipe") // Analyses that need a crafted element, Search returns a lone leaf
+	ErrUnreachable      = errors.New("no recipe tree found")
+	ErrTimeout          = errors.New("search timed out")
+)
+
+// A search option out of its range
+type InvalidParameterError struct {
+	Parameter string
+	Message   string
+}
+
+func (e *InvalidParameterError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Parameter, e.Message)
+}
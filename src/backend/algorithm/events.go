@@ -0,0 +1,104 @@
+package algorithm
+
+import (
+	"backend/search"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	EventNodeVisited = "node_visited" // An element was taken from the queue or stack
+	EventRecipeTried = "recipe_tried" // A recipe passed the filters and was added to the search
+	EventLevelDone   = "level_done"   // ReverseBFS finished one level
+	EventTreeFound   = "tree_found"   // A complete recipe tree was found
+	EventFinished    = "finished"     // The search is over, carries the final statistics
+)
+
+// Progress of a running search
+type SearchEvent struct {
+	Type         string       `json:"type"`
+	Element      string       `json:"element,omitempty"`
+	Ingredients  []string     `json:"ingredients,omitempty"`
+	Depth        int          `json:"depth"`               // BFS level of the event. Always 0 for DFS
+	Tree         *RecipeTree  `json:"tree,omitempty"`      // tree_found only
+	VisitedNodes int          `json:"visitedNodes"`        // Nodes visited so far
+	Trees        int          `json:"trees,omitempty"`     // finished only
+	Elapsed      int64        `json:"elapsedMs,omitempty"` // finished only
+	Message      string       `json:"message,omitempty"`   // finished only, set when the search failed
+	Stats        *SearchStats `json:"stats,omitempty"`     // finished only
+}
+
+// Receives the events of one search. A nil observer ignores every event,
+// so the algorithms can always emit. Once ctx is done, emitting stops
+// blocking and the algorithms stop as soon as they can
+type SearchObserver struct {
+	ctx    context.Context
+	events chan<- SearchEvent
+}
+
+func NewSearchObserver(ctx context.Context, events chan<- SearchEvent) *SearchObserver {
+	return &SearchObserver{ctx: ctx, events: events}
+}
+
+func (o *SearchObserver) emit(event SearchEvent) {
+	if o == nil {
+		return
+	}
+	select {
+	case o.events <- event:
+	case <-o.ctx.Done():
+	}
+}
+
+// Runs algo for target and sends its progress to the observer. BFS reports
+// every level and DFS every node and recipe, the other algorithms only report
+// the trees they found. The observer channel is closed when the search is over
+func StreamSearch(algo string, target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, observer *SearchObserver) {
+	defer close(observer.events)
+	ctx := observer.ctx
+	startTime := time.Now()
+	stats := NewStatsCollector()
+
+	var trees []*RecipeTree
+	var nodeVisited int
+	switch algo {
+	case "bfs":
+		if isBaseElement(target) {
+			trees = BFS(ctx, target, graph, maxPaths, false, &nodeVisited, stats)
+			break
+		}
+		big, visited := reverseBFS(ctx, target, 1, observer, stats)
+		nodeVisited = visited
+		trees = ExpandTrees(*big, target, maxPaths, false, stats)
+	case "dfs":
+		trees = findMultiplePaths(ctx, target, graph, maxPaths, false, &nodeVisited, observer, stats)
+	case "astar":
+		trees = AStar(ctx, target, graph, maxPaths, false, &nodeVisited, stats)
+	case "iddfs":
+		trees, _ = IDDFS(ctx, target, graph, maxPaths, 0, false, &nodeVisited, stats)
+	case "bidirectional":
+		var visits BidirectionalVisits
+		trees = Bidirectional(ctx, target, graph, maxPaths, false, &visits, stats)
+		nodeVisited = visits.Forward + visits.Backward
+	default:
+		observer.emit(SearchEvent{Type: EventFinished, Message: fmt.Sprintf("unknown algorithm %s", algo)})
+		return
+	}
+
+	// DFS already reported its trees while searching
+	if algo != "dfs" {
+		for _, tree := range trees {
+			observer.emit(SearchEvent{Type: EventTreeFound, Element: target.Name, Tree: tree, VisitedNodes: nodeVisited})
+		}
+	}
+	finalStats := stats.Finish(nodeVisited)
+	observer.emit(SearchEvent{
+		Type:         EventFinished,
+		Element:      target.Name,
+		VisitedNodes: nodeVisited,
+		Trees:        len(trees),
+		Elapsed:      time.Since(startTime).Milliseconds(),
+		Stats:        &finalStats,
+	})
+}
@@ -0,0 +1,166 @@
+package algorithm
+
+import (
+	"backend/search"
+	"context"
+)
+
+type depthLimitedSearch struct {
+	ctx         context.Context
+	graph       *search.RecipeGraph
+	target      *search.ElementNode
+	maxPaths    int
+	dedup       bool
+	nodeVisited *int
+	stats       *StatsCollector
+
+	// Largest depth limit for which an element is known to have no tree.
+	// Kept for one iteration only, so memory stays linear in the graph size
+	dead map[int]int
+
+	// Trees of the sub-searches that found some. They only depend on the
+	// element and the limit, so they are shared by every iteration, and
+	// other searches find them in elementCache
+	found map[depthLimit][]*TreeNode
+}
+
+type depthLimit struct {
+	element int
+	limit   int
+}
+
+// Iterative-deepening DFS. Runs a depth-limited DFS with limit 0, 1, ...
+// maxDepth and stops at the first limit that yields a tree, so every tree
+// returned is as shallow as possible. maxDepth <= 0 means the tier of target,
+// which no valid tree can exceed. Returns the trees and the limit they were
+// found at, or -1 if none was found. With dedup, every element only gets
+// structurally distinct subtrees
+func IDDFS(ctx context.Context, target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, maxDepth int, dedup bool, nodeVisited *int, stats *StatsCollector) ([]*RecipeTree, int) {
+	if maxDepth <= 0 {
+		maxDepth = target.Tier
+	}
+
+	key := CacheKey{Algorithm: "iddfs", Element: target.ID, MaxPaths: maxPaths, MaxDepth: maxDepth, Dedup: dedup}
+	cache := resultCacheFor(ctx)
+	if entry, ok := cache.get(graph, key); ok {
+		*nodeVisited = entry.nodeVisited
+		stats.addDuplicates(entry.duplicates)
+		stats.markCached()
+		return treesFromRoots(entry.roots), entry.depth
+	}
+
+	duplicates := stats.duplicateCount()
+	trees, depth := iterativeDeepening(ctx, target, graph, maxPaths, maxDepth, dedup, nodeVisited, stats)
+	roots := rootsFromTrees(trees)
+	if ctx.Err() != nil {
+		return treesFromRoots(roots), depth
+	}
+	cache.put(graph, &cacheEntry{key: key, roots: roots, nodeVisited: *nodeVisited,
+		duplicates: stats.duplicateCount() - duplicates, depth: depth})
+	return treesFromRoots(roots), depth
+}
+
+func iterativeDeepening(ctx context.Context, target *search.ElementNode, graph *search.RecipeGraph, maxPaths int, maxDepth int, dedup bool, nodeVisited *int, stats *StatsCollector) ([]*RecipeTree, int) {
+	found := make(map[depthLimit][]*TreeNode)
+	for limit := 0; limit <= maxDepth && ctx.Err() == nil; limit++ {
+		s := &depthLimitedSearch{
+			ctx:         ctx,
+			graph:       graph,
+			target:      target,
+			maxPaths:    maxPaths,
+			dedup:       dedup,
+			nodeVisited: nodeVisited,
+			stats:       stats,
+			dead:        make(map[int]int),
+			found:       found,
+		}
+
+		roots := s.search(target, limit)
+		if len(roots) == 0 {
+			continue
+		}
+
+		trees := make([]*RecipeTree, 0, len(roots))
+		for _, root := range roots {
+			trees = append(trees, NewRecipeTree(root))
+		}
+		return trees, limit
+	}
+
+	return []*RecipeTree{}, -1
+}
+
+// All trees for node no deeper than limit, at most maxPaths of them. Trees
+// from elementCache count the nodes their search visited, like in
+// findSinglePath
+func (s *depthLimitedSearch) search(node *search.ElementNode, limit int) []*TreeNode {
+	visitedBefore := *s.nodeVisited
+	*s.nodeVisited++
+
+	if isBaseElement(node) {
+		return []*TreeNode{NewLeaf(node)}
+	}
+	if limit == 0 || s.ctx.Err() != nil {
+		return nil
+	}
+	if deadLimit, ok := s.dead[node.ID]; ok && limit <= deadLimit {
+		return nil
+	}
+	key := depthLimit{node.ID, limit}
+	if trees, ok := s.found[key]; ok {
+		return trees
+	}
+	cacheKey := CacheKey{Algorithm: "iddfs", Element: node.ID, MaxPaths: s.maxPaths, MaxDepth: limit, Dedup: s.dedup}
+	if entry, ok := elementCacheFor(s.ctx).get(s.graph, cacheKey); ok {
+		*s.nodeVisited = visitedBefore + entry.nodeVisited
+		if node == s.target {
+			s.stats.addDuplicates(entry.duplicates)
+		}
+		s.stats.markCached()
+		s.found[key] = entry.roots
+		return entry.roots
+	}
+
+	trees := make([]*TreeNode, 0)
+	kept := newCanonicalSet(s.dedup)
+	duplicates := 0
+recipes:
+	for i, recipe := range node.Recipes {
+		if !s.stats.checkRecipe(node, recipe) {
+			continue
+		}
+
+		lefts := s.search(recipe[0], limit-1)
+		if len(lefts) == 0 {
+			continue
+		}
+		rights := s.search(recipe[1], limit-1)
+		for _, left := range lefts {
+			for _, right := range rights {
+				craft := NewCraft(node, i, left, right)
+				if !kept.add(craft) {
+					duplicates++
+					continue
+				}
+				trees = append(trees, craft)
+				if s.maxPaths > 0 && len(trees) >= s.maxPaths {
+					break recipes
+				}
+			}
+		}
+	}
+
+	if node == s.target {
+		s.stats.addDuplicates(duplicates)
+	}
+	switch {
+	case s.ctx.Err() != nil:
+		// A stopped search may have missed trees
+	case len(trees) == 0:
+		s.dead[node.ID] = limit
+	default:
+		s.found[key] = trees
+		elementCacheFor(s.ctx).put(s.graph, &cacheEntry{key: cacheKey, roots: trees, nodeVisited: *s.nodeVisited - visitedBefore, duplicates: duplicates})
+	}
+	return trees
+}
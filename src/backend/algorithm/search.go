@@ -0,0 +1,110 @@
+package algorithm
+
+import (
+	"backend/search"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type SearchOptions struct {
+	MaxPaths int
+	MaxDepth int  // IDDFS only, 0 for the tier of the target
+	Dedup    bool // Skip structurally equal trees, they do not count towards MaxPaths
+	NoCache  bool // Neither read nor fill the shared caches, e.g. to compare algorithms
+}
+
+type SearchResult struct {
+	Algorithm    string
+	Trees        []*RecipeTree
+	VisitedNodes int
+	Duplicates   int                  // Trees skipped by Dedup
+	Visits       *BidirectionalVisits // bidirectional only
+	Depth        int                  // iddfs only, the depth limit the trees were found at
+	Stats        SearchStats
+}
+
+var algorithmNames = []string{"bfs", "dfs", "bidirectional", "iddfs", "astar"}
+
+// Names accepted by Search
+func Algorithms() []string {
+	return slices.Clone(algorithmNames)
+}
+
+func IsAlgorithm(algo string) bool {
+	return slices.Contains(algorithmNames, algo)
+}
+
+// Runs algo for target. The search gives up with ErrTimeout once ctx
+// expires, and the algorithm stops as soon as it notices. Fails with
+// ErrUnreachable when no tree was found. A base element is its own tree
+func Search(ctx context.Context, algo string, target *search.ElementNode, graph *search.RecipeGraph, options SearchOptions) (*SearchResult, error) {
+	if !IsAlgorithm(algo) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownAlgorithm, algo)
+	}
+	if options.MaxPaths <= 0 {
+		return nil, &InvalidParameterError{Parameter: "max", Message: "must be greater than 0"}
+	}
+	if options.MaxDepth < 0 {
+		return nil, &InvalidParameterError{Parameter: "maxDepth", Message: "must not be negative"}
+	}
+
+	type outcome struct {
+		result *SearchResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		// A panic in a goroutine would take the whole server down
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("%s search for %s panicked: %v", algo, target.Name, r)}
+			}
+		}()
+		done <- outcome{result: runSearch(ctx, algo, target, graph, options)}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil {
+			return nil, o.err
+		}
+		if len(o.result.Trees) == 0 {
+			return o.result, fmt.Errorf("%w for %s", ErrUnreachable, target.Name)
+		}
+		return o.result, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %s search for %s", ErrTimeout, algo, target.Name)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func runSearch(ctx context.Context, algo string, target *search.ElementNode, graph *search.RecipeGraph, options SearchOptions) *SearchResult {
+	stats := NewStatsCollector()
+	result := &SearchResult{Algorithm: algo}
+	if options.NoCache {
+		ctx = withPrivateCache(ctx)
+	}
+
+	switch algo {
+	case "bfs":
+		result.Trees = BFS(ctx, target, graph, options.MaxPaths, options.Dedup, &result.VisitedNodes, stats)
+	case "dfs":
+		result.Trees = DFS(ctx, target, graph, options.MaxPaths, options.Dedup, &result.VisitedNodes, stats)
+	case "astar":
+		result.Trees = AStar(ctx, target, graph, options.MaxPaths, options.Dedup, &result.VisitedNodes, stats)
+	case "bidirectional":
+		result.Visits = &BidirectionalVisits{}
+		result.Trees = Bidirectional(ctx, target, graph, options.MaxPaths, options.Dedup, result.Visits, stats)
+		result.VisitedNodes = result.Visits.Forward + result.Visits.Backward
+	case "iddfs":
+		result.Trees, result.Depth = IDDFS(ctx, target, graph, options.MaxPaths, options.MaxDepth, options.Dedup, &result.VisitedNodes, stats)
+	}
+
+	result.Duplicates = stats.duplicateCount()
+	result.Stats = stats.Finish(result.VisitedNodes)
+	return result
+}
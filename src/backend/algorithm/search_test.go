@@ -0,0 +1,474 @@
+package algorithm_test
+
+import (
+	"backend/algorithm"
+	"backend/algorithm/algotest"
+	"backend/search"
+	"backend/search/graphtest"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAlgorithmsOnSmallGraphs(t *testing.T) {
+	graphs := map[string]*graphtest.Builder{
+		"chain":   graphtest.Chain(),
+		"diamond": graphtest.Diamond(),
+		"pruned":  graphtest.Pruned(),
+	}
+	for name, builder := range graphs {
+		graph := builder.Graph(t)
+		for _, algo := range algorithm.Algorithms() {
+			t.Run(name+"/"+algo, func(t *testing.T) {
+				algotest.CheckAlgorithm(t, algo, graph)
+			})
+		}
+	}
+}
+
+func TestAlgorithmsOnRandomGraphs(t *testing.T) {
+	seeds := 20
+	if testing.Short() {
+		seeds = 3
+	}
+	for seed := range int64(seeds) {
+		graph := graphtest.Random(seed, 25).Graph(t)
+		for _, algo := range algorithm.Algorithms() {
+			t.Run(fmt.Sprintf("seed%d/%s", seed, algo), func(t *testing.T) {
+				algotest.CheckAlgorithm(t, algo, graph)
+			})
+		}
+	}
+}
+
+func TestAlgorithmsOnSnapshot(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	for _, algo := range algorithm.Algorithms() {
+		t.Run(algo, func(t *testing.T) {
+			algotest.CheckAlgorithm(t, algo, graph)
+		})
+	}
+}
+
+// IDDFS stops at the first depth limit that has a tree, so no algorithm
+// finds a tree shallower than the ones of IDDFS
+func TestIDDFSIsShallowest(t *testing.T) {
+	graphs := []*search.RecipeGraph{graphtest.Snapshot(t)}
+	for seed := range int64(10) {
+		graphs = append(graphs, graphtest.Random(seed, 25).Graph(t))
+	}
+	options := algorithm.SearchOptions{MaxPaths: 3}
+	for _, graph := range graphs {
+		reachable := algotest.Reachable(graph)
+		for _, target := range graph.Elements[1:] {
+			if !reachable[target] || slices.Contains(graph.BaseElements, target) {
+				continue
+			}
+			iddfs, err := algotest.Search(t, "iddfs", graph, target, options)
+			if err != nil {
+				t.Fatalf("iddfs %s: %v", target.Name, err)
+			}
+			if iddfs.Trees[0].Depth != iddfs.Depth {
+				t.Fatalf("%s: iddfs tree of depth %d at limit %d", target.Name, iddfs.Trees[0].Depth, iddfs.Depth)
+			}
+			for _, algo := range algorithm.Algorithms() {
+				result, err := algotest.Search(t, algo, graph, target, options)
+				if err != nil {
+					t.Fatalf("%s %s: %v", algo, target.Name, err)
+				}
+				for _, tree := range result.Trees {
+					if tree.Depth < iddfs.Depth {
+						t.Errorf("%s: %s found a tree of depth %d, iddfs stopped at %d\n%s", target.Name, algo,
+							tree.Depth, iddfs.Depth, algotest.Notation(tree))
+					}
+				}
+			}
+		}
+	}
+}
+
+// Trees of every element of the snapshot, one golden file per algorithm.
+// Run with -update after changing what an algorithm returns on purpose
+func TestGolden(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	options := algorithm.SearchOptions{MaxPaths: 3, Dedup: true}
+	for _, algo := range algorithm.Algorithms() {
+		t.Run(algo, func(t *testing.T) {
+			var b strings.Builder
+			for _, target := range graph.Elements[1:] {
+				result, err := algotest.Search(t, algo, graph, target, options)
+				if err != nil {
+					fmt.Fprintf(&b, "%s: %v\n", target.Name, err)
+					continue
+				}
+				for _, tree := range result.Trees {
+					fmt.Fprintf(&b, "%s: depth %d, %d crafts: %s\n", target.Name, tree.Depth, tree.Crafts, algotest.Notation(tree))
+				}
+			}
+			algotest.Golden(t, "golden/"+algo, b.String())
+		})
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	graph := graphtest.Diamond().Graph(t)
+	stone, _ := search.GetElementByName(graph, "Stone")
+	tests := []struct {
+		name    string
+		algo    string
+		options algorithm.SearchOptions
+		want    func(error) bool
+	}{
+		{"unknown algorithm", "dijkstra", algorithm.SearchOptions{MaxPaths: 1}, func(err error) bool {
+			return errors.Is(err, algorithm.ErrUnknownAlgorithm)
+		}},
+		{"max of 0", "bfs", algorithm.SearchOptions{}, func(err error) bool {
+			var invalid *algorithm.InvalidParameterError
+			return errors.As(err, &invalid) && invalid.Parameter == "max"
+		}},
+		{"negative max depth", "iddfs", algorithm.SearchOptions{MaxPaths: 1, MaxDepth: -1}, func(err error) bool {
+			var invalid *algorithm.InvalidParameterError
+			return errors.As(err, &invalid) && invalid.Parameter == "maxDepth"
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := algorithm.Search(context.Background(), test.algo, stone, graph, test.options)
+			if !test.want(err) {
+				t.Errorf("got %v", err)
+			}
+		})
+	}
+}
+
+// Trees that only differ in the order of ingredients do not count towards
+// max, so with Dedup a search still gives max trees when there are that many
+func TestDedupFillsMax(t *testing.T) {
+	// Mud has the same recipe twice and the two Bricks of a Wall can be
+	// swapped. Wall has 3 distinct trees out of 16
+	graph := graphtest.New().
+		Add("Mud", "Water", "Earth").
+		Add("Mud", "Earth", "Water").
+		Add("Brick", "Mud", "Fire").
+		Add("Brick", "Mud", "Water").
+		Add("Wall", "Brick", "Brick").
+		Graph(t)
+	wall, _ := search.GetElementByName(graph, "Wall")
+	options := algorithm.SearchOptions{MaxPaths: 3, Dedup: true}
+	for _, algo := range algorithm.Algorithms() {
+		t.Run(algo, func(t *testing.T) {
+			algorithm.InvalidateCache()
+			result, err := algotest.Search(t, algo, graph, wall, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			algotest.CheckResult(t, graph, wall, options, result)
+			if len(result.Trees) != 3 {
+				t.Errorf("%d trees, want 3", len(result.Trees))
+			}
+		})
+	}
+}
+
+// Bidirectional keeps expanding after the first stitch until it has max trees,
+// and each side visits an element at most once
+func TestBidirectionalExpandsUntilMax(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	life, _ := search.GetElementByName(graph, "Life")
+	// The first level where both halves meet only gives two trees of Life
+	result, err := algotest.Search(t, "bidirectional", graph, life, algorithm.SearchOptions{MaxPaths: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Trees) != 3 {
+		t.Errorf("Life: %d trees, want 3", len(result.Trees))
+	}
+
+	for _, target := range graph.Elements[1:] {
+		result, _ := algotest.Search(t, "bidirectional", graph, target, algorithm.SearchOptions{MaxPaths: 10})
+		if result.Visits.Forward > len(graph.Elements)-1 || result.Visits.Backward > len(graph.Elements)-1 {
+			t.Errorf("%s: visited %d elements forward and %d backward, the graph has %d",
+				target.Name, result.Visits.Forward, result.Visits.Backward, len(graph.Elements)-1)
+		}
+	}
+}
+
+// Stitching builds the trees found forward again by splitting them. Those are
+// not duplicates, only trees of other recipes with the same structure are
+func TestBidirectionalDuplicates(t *testing.T) {
+	graph := graphtest.Diamond().Graph(t)
+	for _, target := range graph.Elements[1:] {
+		result, err := algotest.Search(t, "bidirectional", graph, target, algorithm.SearchOptions{MaxPaths: 10, Dedup: true, NoCache: true})
+		if err != nil {
+			t.Fatalf("%s: %v", target.Name, err)
+		}
+		if result.Duplicates != 0 {
+			t.Errorf("%s: %d duplicates, the graph has none", target.Name, result.Duplicates)
+		}
+	}
+
+	// The two recipes of Mud give Wall 16 trees, only 3 of them distinct
+	graph = graphtest.New().
+		Add("Mud", "Water", "Earth").
+		Add("Mud", "Earth", "Water").
+		Add("Brick", "Mud", "Fire").
+		Add("Brick", "Mud", "Water").
+		Add("Wall", "Brick", "Brick").
+		Graph(t)
+	wall, _ := search.GetElementByName(graph, "Wall")
+	result, err := algotest.Search(t, "bidirectional", graph, wall, algorithm.SearchOptions{MaxPaths: 10, Dedup: true, NoCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Trees) != 3 || result.Duplicates == 0 {
+		t.Errorf("Wall: %d trees and %d duplicates, want 3 and some", len(result.Trees), result.Duplicates)
+	}
+}
+
+// A* counts elements like BFS, not partial trees. An element crafted from two
+// base elements is one expansion and two base elements for both
+func TestAStarCountsElements(t *testing.T) {
+	graph := graphtest.Diamond().Graph(t)
+	for _, name := range []string{"Lava", "Pressure"} {
+		target, _ := search.GetElementByName(graph, name)
+		options := algorithm.SearchOptions{MaxPaths: 1, NoCache: true}
+		bfs, err := algotest.Search(t, "bfs", graph, target, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		astar, err := algotest.Search(t, "astar", graph, target, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if astar.VisitedNodes != 3 || bfs.VisitedNodes != 3 {
+			t.Errorf("%s: astar visited %d nodes and bfs %d, want 3", name, astar.VisitedNodes, bfs.VisitedNodes)
+		}
+	}
+}
+
+// Every algorithm gives a recipe breaking both rules the same prune reason
+func TestPruneReasons(t *testing.T) {
+	// The recipe comes first, so the searches stopping at the first tree
+	// check it too
+	graph := graphtest.New().
+		Uncraftable("Time").
+		Tier("Time", 5).
+		Add("Steam", "Time", "Water").
+		Add("Steam", "Water", "Fire").
+		Graph(t)
+	steam, _ := search.GetElementByName(graph, "Steam")
+	for _, algo := range algorithm.Algorithms() {
+		result, err := algotest.Search(t, algo, graph, steam, algorithm.SearchOptions{MaxPaths: 1, NoCache: true})
+		if err != nil {
+			t.Fatalf("%s: %v", algo, err)
+		}
+		if pruned := result.Stats.Pruned; pruned.NoRecipe != 0 || pruned.Tier == 0 {
+			t.Errorf("%s: pruned %+v, want the tier rule only", algo, pruned)
+		}
+	}
+}
+
+// A crafted element ReverseBFS found no recipe for is not a leaf, trees going
+// through it are dropped
+func TestExpandTreesNeedsRecipes(t *testing.T) {
+	graph := graphtest.Diamond().Graph(t)
+	stone, _ := search.GetElementByName(graph, "Stone")
+	big := algorithm.GraphJSONWithRecipes{Recipes: []algorithm.JSONRecipe{
+		{Ingredients: []string{"Lava", "Air"}, Result: "Stone", Step: 0},
+		{Ingredients: []string{"Earth", "Pressure"}, Result: "Stone", Step: 0},
+		{Ingredients: []string{"Air", "Air"}, Result: "Pressure", Step: 1},
+	}}
+	trees := algorithm.ExpandTrees(big, stone, 0, false, nil)
+	if len(trees) != 1 || algotest.Notation(trees[0]) != "Stone(Earth, Pressure(Air, Air))" {
+		for _, tree := range trees {
+			t.Log(algotest.Notation(tree))
+		}
+		t.Fatalf("got %d trees, want only the one through Pressure", len(trees))
+	}
+}
+
+// A search stopped by its context may have missed trees, so the next search
+// for the same element must run again instead of reading them from the cache
+func TestCancelledSearchIsNotCached(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	reachable := algotest.Reachable(graph)
+	var target *search.ElementNode
+	for _, element := range graph.Elements[1:] {
+		if reachable[element] && (target == nil || element.Tier > target.Tier) {
+			target = element
+		}
+	}
+	options := algorithm.SearchOptions{MaxPaths: 3}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, algo := range algorithm.Algorithms() {
+		t.Run(algo, func(t *testing.T) {
+			algorithm.InvalidateCache()
+			algorithm.Search(ctx, algo, target, graph, options)
+			result, err := algotest.Search(t, algo, graph, target, options)
+			if err != nil {
+				t.Fatalf("%s: %v", target.Name, err)
+			}
+			if result.Stats.Cached {
+				t.Errorf("%s: the trees of the cancelled search were cached", target.Name)
+			}
+			algotest.CheckResult(t, graph, target, options, result)
+		})
+	}
+}
+
+// A search with NoCache neither reads nor fills the shared caches, and visits
+// as many nodes as one starting with empty caches
+func TestNoCacheSkipsSharedCache(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	target, _ := search.GetElementByName(graph, "Life")
+	for _, algo := range algorithm.Algorithms() {
+		t.Run(algo, func(t *testing.T) {
+			options := algorithm.SearchOptions{MaxPaths: 3}
+			algorithm.InvalidateCache()
+			cold, err := algotest.Search(t, algo, graph, target, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			options.NoCache = true
+			before := algorithm.GetCacheStatistic()
+			result, err := algotest.Search(t, algo, graph, target, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Stats.Cached {
+				t.Error("the search read the shared cache")
+			}
+			if result.VisitedNodes != cold.VisitedNodes {
+				t.Errorf("%d nodes visited, %d with empty caches", result.VisitedNodes, cold.VisitedNodes)
+			}
+			if after := algorithm.GetCacheStatistic(); after != before {
+				t.Errorf("the search used the shared cache: %+v, then %+v", before, after)
+			}
+			algotest.CheckResult(t, graph, target, options, result)
+		})
+	}
+}
+
+// BFS reports every node as it takes it from the queue, each with the nodes
+// visited up to and including it
+func TestStreamBFSReportsEachNode(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	target, _ := search.GetElementByName(graph, "Life")
+	events := make(chan algorithm.SearchEvent)
+	go algorithm.StreamSearch("bfs", target, graph, 3, algorithm.NewSearchObserver(context.Background(), events))
+
+	visited, last := 0, 0
+	for event := range events {
+		switch event.Type {
+		case algorithm.EventNodeVisited:
+			if event.VisitedNodes <= last {
+				t.Errorf("%s visited after %d nodes, the node before after %d", event.Element, event.VisitedNodes, last)
+			}
+			visited++
+			last = event.VisitedNodes
+		case algorithm.EventFinished:
+			if event.VisitedNodes != last {
+				t.Errorf("finished after %d nodes, the last node_visited event had %d", event.VisitedNodes, last)
+			}
+		}
+	}
+	if visited < 2 {
+		t.Errorf("%d node_visited events", visited)
+	}
+}
+
+// PeakGoroutines only counts the goroutines of the search, however many the
+// process runs
+func TestPeakGoroutinesPerSearch(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	target, _ := search.GetElementByName(graph, "Life")
+	stop := make(chan struct{})
+	defer close(stop)
+	for range 50 {
+		go func() { <-stop }()
+	}
+
+	want := map[string]int{"bfs": 1 + algorithm.BFSThreads, "astar": 1, "iddfs": 1, "bidirectional": 1}
+	for _, algo := range algorithm.Algorithms() {
+		options := algorithm.SearchOptions{MaxPaths: 3, NoCache: true}
+		result, err := algotest.Search(t, algo, graph, target, options)
+		if err != nil {
+			t.Fatalf("%s: %v", algo, err)
+		}
+		peak := result.Stats.PeakGoroutines
+		if expected, ok := want[algo]; ok && peak != expected {
+			t.Errorf("%s: %d goroutines at most, want %d", algo, peak, expected)
+		}
+		if peak < 1 || peak >= 50 {
+			t.Errorf("%s: %d goroutines at most", algo, peak)
+		}
+	}
+}
+
+// A subtree from the element cache counts the nodes its search visited, so
+// the single recipe DFS visits as many nodes whatever is cached
+func TestElementCacheKeepsVisits(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	options := algorithm.SearchOptions{MaxPaths: 1}
+	algorithm.InvalidateCache()
+	warm := make(map[*search.ElementNode]int)
+	for _, target := range graph.Elements[1:] {
+		result, _ := algotest.Search(t, "dfs", graph, target, options)
+		warm[target] = result.VisitedNodes
+	}
+	for _, target := range graph.Elements[1:] {
+		algorithm.InvalidateCache()
+		result, _ := algotest.Search(t, "dfs", graph, target, options)
+		if result.VisitedNodes != warm[target] {
+			t.Errorf("%s: %d nodes visited with an empty cache, %d with the subtrees of the other elements",
+				target.Name, result.VisitedNodes, warm[target])
+		}
+	}
+}
+
+// A session runs the same expansions as ReverseBFS one at a time, so it must
+// end with the same trees
+func TestSessionMatchesBFS(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	reachable := algotest.Reachable(graph)
+	defer func(maxIterations int) { algorithm.BFSMaxIterations = maxIterations }(algorithm.BFSMaxIterations)
+	// Both stop after the level where they reach the iteration cap
+	for _, maxIterations := range []int{algorithm.BFSMaxIterations, 3} {
+		algorithm.BFSMaxIterations = maxIterations
+		for _, target := range graph.Elements[1:] {
+			if !reachable[target] {
+				continue
+			}
+			bfs, err := algotest.Search(t, "bfs", graph, target, algorithm.SearchOptions{MaxPaths: 3, NoCache: true})
+			if err != nil && !errors.Is(err, algorithm.ErrUnreachable) {
+				t.Fatalf("bfs %s: %v", target.Name, err)
+			}
+
+			session := algorithm.NewSearchSession(target, graph, 3)
+			var trees []string
+			for !session.Finished() {
+				for _, event := range session.Step(10) {
+					if event.Type == algorithm.EventTreeFound {
+						trees = append(trees, algotest.Notation(event.Tree))
+					}
+				}
+			}
+
+			var want []string
+			if bfs != nil {
+				for _, tree := range bfs.Trees {
+					want = append(want, algotest.Notation(tree))
+				}
+			}
+			if !slices.Equal(trees, want) {
+				t.Errorf("%s, at most %d iterations: session found\n%s\nbfs found\n%s", target.Name, maxIterations,
+					strings.Join(trees, "\n"), strings.Join(want, "\n"))
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package algorithm
+
+import (
+	"backend/search"
+	"fmt"
+	"sync"
+)
+
+// An element waiting in the queue of a search session
+type FrontierItem struct {
+	Element string `json:"element"`
+	Depth   int    `json:"depth"`
+}
+
+// A ReverseBFS that runs one expansion at a time, so a client can step
+// through it, inspect its frontier and stop it whenever it wants. Every
+// expansion filters and adds the recipes of one element exactly like a level
+// of ReverseBFS does, and the session stops after the same level once it
+// reaches BFSMaxIterations, so it finds the same trees
+type SearchSession struct {
+	mu sync.Mutex
+
+	target   *search.ElementNode
+	graph    *search.RecipeGraph
+	maxPaths int
+
+	maxIterations int // BFSMaxIterations when the session started
+
+	queue        []QueueItem
+	nodes        []JSONNode
+	recipes      []JSONRecipe
+	includedNode map[int]bool
+	addedRecipe  map[string]bool
+	used         usedCombinations // Not shared with any other search
+
+	visitedNodes int
+	expansions   int
+	iterations   int
+	depth        int // Of the last element expanded
+	trees        []*RecipeTree
+	finished     bool
+}
+
+func NewSearchSession(target *search.ElementNode, graph *search.RecipeGraph, maxPaths int) *SearchSession {
+	s := &SearchSession{
+		target:        target,
+		graph:         graph,
+		maxPaths:      maxPaths,
+		maxIterations: BFSMaxIterations,
+		queue:         make([]QueueItem, 0),
+		nodes:         []JSONNode{{ID: target.ID, Name: target.Name}},
+		recipes:       make([]JSONRecipe, 0),
+		includedNode:  map[int]bool{target.ID: true},
+		addedRecipe:   make(map[string]bool),
+		used:          make(usedCombinations),
+	}
+	if !isBaseElement(target) {
+		s.queue = append(s.queue, QueueItem{
+			Node:          target,
+			AncestryChain: &AncestryChain{Element: target.Name},
+			Depth:         0,
+		})
+	}
+	return s
+}
+
+// Expands at most count elements of the queue and returns what happened.
+// The last events are the trees found and the final statistics once the
+// queue runs out
+func (s *SearchSession) Step(count int) []SearchEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]SearchEvent, 0)
+	for i := 0; i < count && len(s.queue) > 0; i++ {
+		if s.iterations >= s.maxIterations && s.queue[0].Depth > s.depth {
+			s.queue = nil
+			break
+		}
+		item := s.queue[0]
+		s.queue = s.queue[1:]
+		events = append(events, s.expand(item)...)
+	}
+
+	if len(s.queue) == 0 && !s.finished {
+		s.finished = true
+		if s.iterations >= s.maxIterations {
+			warnMaxIterations(s.maxIterations, 1)
+		}
+		if isBaseElement(s.target) {
+			s.visitedNodes++
+			s.trees = []*RecipeTree{NewRecipeTree(NewLeaf(s.target))}
+		} else {
+			big := GraphJSONWithRecipes{Nodes: s.nodes, Recipes: s.recipes}
+			s.trees = ExpandTrees(big, s.target, s.maxPaths, false, nil)
+		}
+
+		for _, tree := range s.trees {
+			events = append(events, SearchEvent{Type: EventTreeFound, Element: s.target.Name, Tree: tree, VisitedNodes: s.visitedNodes})
+		}
+		events = append(events, SearchEvent{Type: EventFinished, Element: s.target.Name, VisitedNodes: s.visitedNodes, Trees: len(s.trees)})
+	}
+	return events
+}
+
+// Must be called with the lock held
+func (s *SearchSession) expand(item QueueItem) []SearchEvent {
+	progress := BFSProgressResult{
+		recipes: make([]JSONRecipe, 0),
+		nodes:   make([]JSONNode, 0),
+	}
+	addRecipes(s.used, item, usableRecipes(item.Node), &progress, func(queued QueueItem) {
+		s.queue = append(s.queue, queued)
+	})
+
+	s.expansions++
+	s.iterations += progress.iteration
+	s.depth = item.Depth
+	s.visitedNodes += progress.visitedNodes
+	events := []SearchEvent{{Type: EventNodeVisited, Element: item.Node.Name, Depth: item.Depth, VisitedNodes: s.visitedNodes}}
+
+	for _, recipe := range progress.recipes {
+		recipeSignature := fmt.Sprintf("%s=%s+%s@%d", recipe.Result, recipe.Ingredients[0], recipe.Ingredients[1], recipe.Step)
+		if s.addedRecipe[recipeSignature] {
+			continue
+		}
+		s.addedRecipe[recipeSignature] = true
+		s.recipes = append(s.recipes, recipe)
+		events = append(events, SearchEvent{Type: EventRecipeTried, Element: recipe.Result, Ingredients: recipe.Ingredients, Depth: recipe.Step, VisitedNodes: s.visitedNodes})
+	}
+	for _, node := range progress.nodes {
+		if !s.includedNode[node.ID] {
+			s.includedNode[node.ID] = true
+			s.nodes = append(s.nodes, node)
+		}
+	}
+	return events
+}
+
+// Elements still waiting to be expanded, in the order they will be
+func (s *SearchSession) Frontier() []FrontierItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	frontier := make([]FrontierItem, 0, len(s.queue))
+	for _, item := range s.queue {
+		frontier = append(frontier, FrontierItem{Element: item.Node.Name, Depth: item.Depth})
+	}
+	return frontier
+}
+
+func (s *SearchSession) Finished() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.finished
+}
+
+func (s *SearchSession) Expansions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expansions
+}
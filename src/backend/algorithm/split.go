@@ -1,86 +1,71 @@
 package algorithm
 
+import "backend/search"
 
-func ExpandPaths(big GraphJSONWithRecipes, target string, maxPaths int) []GraphJSONWithRecipes {
+// Splits the combined recipe graph found by ReverseBFS into at most maxPaths
+// recipe trees for target. maxPaths <= 0 means no limit. With dedup, the
+// subtrees of every element are structurally distinct, and the trees of the
+// target skipped for it are counted in stats
+func ExpandTrees(big GraphJSONWithRecipes, target *search.ElementNode, maxPaths int, dedup bool, stats *StatsCollector) []*RecipeTree {
 	byResult := make(map[string][]JSONRecipe)
 	for _, r := range big.Recipes {
 		byResult[r.Result] = append(byResult[r.Result], r)
 	}
-	nodeByName := make(map[string]JSONNode)
-	for _, n := range big.Nodes {
-		nodeByName[n.Name] = n
-	}
-
-	type recipePath = []JSONRecipe
-	mem := make(map[string][]recipePath)
-	var out []recipePath
 
-	var dfs func(string) []recipePath
-	dfs = func(elem string) []recipePath {
-    recs := byResult[elem]
-    if len(recs) == 0 {
-        return []recipePath{{}}
-    }
-    if cached, ok := mem[elem]; ok {
-        return cached
-    }
-
-    var paths []recipePath
-    for _, r := range recs {
-        prod := []recipePath{{}}
-        for _, ing := range r.Ingredients {
-            ingPaths := dfs(ing)
-            if maxPaths > 0 && len(out)+len(paths) >= maxPaths {
-                break
-            }
-            var next []recipePath
-            for _, base := range prod {
-                for _, add := range ingPaths {
-                    joined := append(append(recipePath{}, base...), add...)
-                    next = append(next, joined)
-                }
-            }
-            prod = next
-        }
-        for _, p := range prod {
-            paths = append(paths, append(append(recipePath{}, p...), r))
-            if maxPaths > 0 && len(out)+len(paths) >= maxPaths {
-                break
-            }
-        }
-        if maxPaths > 0 && len(out)+len(paths) >= maxPaths {
-            break
-        }
-    }
-    mem[elem] = paths
-    return paths
-	}
+	mem := make(map[int][]*TreeNode)
 
+	var dfs func(*search.ElementNode) []*TreeNode
+	dfs = func(elem *search.ElementNode) []*TreeNode {
+		recs := byResult[elem.Name]
+		if isBaseElement(elem) {
+			return []*TreeNode{NewLeaf(elem)}
+		}
+		// An element the search found no recipe for has no tree, it cannot
+		// be a leaf
+		if len(recs) == 0 {
+			return nil
+		}
+		if cached, ok := mem[elem.ID]; ok {
+			return cached
+		}
 
-	out = dfs(target)
-	if maxPaths > 0 && len(out) > maxPaths {
-		out = out[:maxPaths]
-	}
-
-	results := make([]GraphJSONWithRecipes, 0, len(out))
-	for _, recs := range out {
-		seen := map[string]struct{}{target: {}}
+		var trees []*TreeNode
+		kept := newCanonicalSet(dedup)
+		used := make(map[int]bool)
 		for _, r := range recs {
-			seen[r.Result] = struct{}{}
-			for _, ing := range r.Ingredients {
-				seen[ing] = struct{}{}
+			// The same recipe can be found at several steps
+			idx := recipeIndexByName(elem, r.Ingredients[0], r.Ingredients[1])
+			if idx < 0 || used[idx] {
+				continue
 			}
-		}
-		nodes := make([]JSONNode, 0, len(seen))
-		for name := range seen {
-			if n, ok := nodeByName[name]; ok {
-				nodes = append(nodes, n)
+			used[idx] = true
+
+			recipe := elem.Recipes[idx]
+			for _, left := range dfs(recipe[0]) {
+				for _, right := range dfs(recipe[1]) {
+					craft := NewCraft(elem, idx, left, right)
+					if !kept.add(craft) {
+						if elem == target {
+							stats.addDuplicates(1)
+						}
+						continue
+					}
+					trees = append(trees, craft)
+					if maxPaths > 0 && len(trees) >= maxPaths {
+						mem[elem.ID] = trees
+						return trees
+					}
+				}
 			}
 		}
-		results = append(results, GraphJSONWithRecipes{
-			Nodes:   nodes,
-			Recipes: recs,
-		})
+		mem[elem.ID] = trees
+		return trees
+	}
+
+	roots := dfs(target)
+	results := make([]*RecipeTree, 0, len(roots))
+	for _, root := range roots {
+		results = append(results, NewRecipeTree(root))
 	}
 	return results
 }
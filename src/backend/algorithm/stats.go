@@ -0,0 +1,176 @@
+package algorithm
+
+import (
+	"backend/search"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	PruneTier     = "tier"      // An ingredient does not have a lower tier than the result
+	PruneNoRecipe = "no_recipe" // An ingredient cannot be crafted and is not a base element
+	PruneDedup    = "dedup"     // The combination was already used on the same ancestry
+)
+
+type PrunedRecipes struct {
+	Tier     int64 `json:"tier"`
+	NoRecipe int64 `json:"noRecipe"`
+	Dedup    int64 `json:"dedup"`
+}
+
+// Execution statistics of one search
+type SearchStats struct {
+	DurationMs     float64       `json:"durationMs"`
+	NodesVisited   int           `json:"nodesVisited"`
+	RecipesTried   int64         `json:"recipesTried"`
+	Pruned         PrunedRecipes `json:"pruned"`
+	PeakGoroutines int           `json:"peakGoroutines"` // Goroutines of this search running at once, its caller included
+
+	// Heap allocated by the whole process while the search ran, including
+	// the other requests served at the same time
+	ProcessBytesAllocated uint64 `json:"processBytesAllocated"`
+
+	Cached bool `json:"cached"` // The trees came from the subtree cache
+}
+
+// Collects SearchStats while a search runs. Safe for concurrent use, and a
+// nil collector ignores everything so the algorithms can always record
+type StatsCollector struct {
+	startTime  time.Time
+	startAlloc uint64
+
+	recipesTried   atomic.Int64
+	prunedTier     atomic.Int64
+	prunedNoRecipe atomic.Int64
+	prunedDedup    atomic.Int64
+	goroutines     atomic.Int64 // Running for this search
+	peakGoroutines atomic.Int64
+	cached         atomic.Bool
+	duplicates     atomic.Int64
+
+	finishOnce sync.Once
+	stats      SearchStats
+}
+
+func NewStatsCollector() *StatsCollector {
+	c := &StatsCollector{startTime: time.Now(), startAlloc: heapAllocated()}
+	c.goroutinesStarted(1)
+	return c
+}
+
+// Bytes allocated on the heap since the process started. Unlike
+// runtime.ReadMemStats, reading it does not stop the world
+func heapAllocated() uint64 {
+	sample := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+func (c *StatsCollector) tryRecipe() {
+	if c != nil {
+		c.recipesTried.Add(1)
+	}
+}
+
+func (c *StatsCollector) prune(reason string) {
+	if c == nil {
+		return
+	}
+	switch reason {
+	case PruneTier:
+		c.prunedTier.Add(1)
+	case PruneNoRecipe:
+		c.prunedNoRecipe.Add(1)
+	case PruneDedup:
+		c.prunedDedup.Add(1)
+	}
+}
+
+// Adds counters gathered separately, as the ReverseBFS workers do
+func (c *StatsCollector) add(tried, tier, noRecipe, dedup int) {
+	if c == nil {
+		return
+	}
+	c.recipesTried.Add(int64(tried))
+	c.prunedTier.Add(int64(tier))
+	c.prunedNoRecipe.Add(int64(noRecipe))
+	c.prunedDedup.Add(int64(dedup))
+}
+
+// Records n goroutines started by the search
+func (c *StatsCollector) goroutinesStarted(n int) {
+	if c == nil {
+		return
+	}
+	current := c.goroutines.Add(int64(n))
+	for {
+		peak := c.peakGoroutines.Load()
+		if current <= peak || c.peakGoroutines.CompareAndSwap(peak, current) {
+			return
+		}
+	}
+}
+
+// Records n goroutines of the search that returned
+func (c *StatsCollector) goroutinesDone(n int) {
+	if c != nil {
+		c.goroutines.Add(-int64(n))
+	}
+}
+
+// Counts trees of the target skipped because the search already had one of
+// the same structure
+func (c *StatsCollector) addDuplicates(count int) {
+	if c != nil {
+		c.duplicates.Add(int64(count))
+	}
+}
+
+func (c *StatsCollector) duplicateCount() int {
+	if c == nil {
+		return 0
+	}
+	return int(c.duplicates.Load())
+}
+
+func (c *StatsCollector) markCached() {
+	if c != nil {
+		c.cached.Store(true)
+	}
+}
+
+// Checks a recipe of node like IsUsableRecipe and records the outcome
+func (c *StatsCollector) checkRecipe(node *search.ElementNode, recipe []*search.ElementNode) bool {
+	reason := recipePruneReason(node, recipe)
+	if reason != "" {
+		c.prune(reason)
+		return false
+	}
+	c.tryRecipe()
+	return true
+}
+
+// Stops the clock. Only the first call counts
+func (c *StatsCollector) Finish(nodesVisited int) SearchStats {
+	c.finishOnce.Do(func() {
+		c.stats = SearchStats{
+			DurationMs:   float64(time.Since(c.startTime).Microseconds()) / 1000,
+			NodesVisited: nodesVisited,
+			RecipesTried: c.recipesTried.Load(),
+			Pruned: PrunedRecipes{
+				Tier:     c.prunedTier.Load(),
+				NoRecipe: c.prunedNoRecipe.Load(),
+				Dedup:    c.prunedDedup.Load(),
+			},
+			PeakGoroutines:        int(c.peakGoroutines.Load()),
+			ProcessBytesAllocated: heapAllocated() - c.startAlloc,
+			Cached:                c.cached.Load(),
+		}
+	})
+	return c.stats
+}
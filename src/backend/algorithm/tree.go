@@ -0,0 +1,120 @@
+package algorithm
+
+import (
+	"backend/search"
+	"slices"
+)
+
+// Canonical result of every search algorithm. See recipe_tree.schema.json
+// A recipe tree is rooted at the target, every internal node is an element
+// crafted from its two ingredients and every leaf is a base element
+type RecipeTree struct {
+	Target string    `json:"target"` // Name of the root element
+	Depth  int       `json:"depth"`  // Depth of the deepest leaf, a lone base element is 0
+	Crafts int       `json:"crafts"` // Number of combinations in the tree
+	Root   *TreeNode `json:"root"`
+}
+
+type TreeNode struct {
+	ID          int         `json:"id"`          // Element ID in the RecipeGraph, stable for one dataset
+	Name        string      `json:"name"`        // Name of the element
+	Tier        int         `json:"tier"`        // Tier of the element
+	Depth       int         `json:"depth"`       // Distance from the root. Root is 0
+	Recipe      int         `json:"recipe"`      // Index of the chosen recipe in ElementNode.Recipes. -1 for leaves
+	Ingredients []*TreeNode `json:"ingredients"` // The two ingredients of the chosen recipe. Empty for leaves
+}
+
+func NewLeaf(element *search.ElementNode) *TreeNode {
+	return &TreeNode{
+		ID:          element.ID,
+		Name:        element.Name,
+		Tier:        element.Tier,
+		Recipe:      -1,
+		Ingredients: []*TreeNode{},
+	}
+}
+
+func NewCraft(element *search.ElementNode, recipe int, ingredient0, ingredient1 *TreeNode) *TreeNode {
+	return &TreeNode{
+		ID:          element.ID,
+		Name:        element.Name,
+		Tier:        element.Tier,
+		Recipe:      recipe,
+		Ingredients: []*TreeNode{ingredient0, ingredient1},
+	}
+}
+
+// Wraps a root into a RecipeTree. Subtrees may be shared between several
+// roots during enumeration, so the tree is copied while the depths are set
+func NewRecipeTree(root *TreeNode) *RecipeTree {
+	tree := &RecipeTree{Target: root.Name}
+	tree.Root = copyTreeNode(root, 0, tree)
+	return tree
+}
+
+func copyTreeNode(node *TreeNode, depth int, tree *RecipeTree) *TreeNode {
+	copied := &TreeNode{
+		ID:          node.ID,
+		Name:        node.Name,
+		Tier:        node.Tier,
+		Depth:       depth,
+		Recipe:      node.Recipe,
+		Ingredients: make([]*TreeNode, 0, len(node.Ingredients)),
+	}
+	if depth > tree.Depth {
+		tree.Depth = depth
+	}
+	if len(node.Ingredients) > 0 {
+		tree.Crafts++
+	}
+	for _, ingredient := range node.Ingredients {
+		copied.Ingredients = append(copied.Ingredients, copyTreeNode(ingredient, depth+1, tree))
+	}
+	return copied
+}
+
+// Index of the recipe of element made of the two ingredients, in either order. -1 if none
+func recipeIndex(element *search.ElementNode, ingredient0, ingredient1 *search.ElementNode) int {
+	for i, recipe := range element.Recipes {
+		if recipe[0] == ingredient0 && recipe[1] == ingredient1 {
+			return i
+		}
+	}
+	for i, recipe := range element.Recipes {
+		if recipe[0] == ingredient1 && recipe[1] == ingredient0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Same as recipeIndex, but the ingredients are given by name
+func recipeIndexByName(element *search.ElementNode, ingredient0, ingredient1 string) int {
+	for i, recipe := range element.Recipes {
+		if recipe[0].Name == ingredient0 && recipe[1].Name == ingredient1 {
+			return i
+		}
+	}
+	for i, recipe := range element.Recipes {
+		if recipe[0].Name == ingredient1 && recipe[1].Name == ingredient0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Converts a DFS recipe into a tree node
+func treeFromRecipe(recipe *Recipe, graph *search.RecipeGraph) *TreeNode {
+	if slices.Contains(graph.BaseElements, recipe.element) {
+		return NewLeaf(recipe.element)
+	}
+
+	ingredient0 := recipe.composition[0]
+	ingredient1 := recipe.composition[1]
+	return NewCraft(
+		recipe.element,
+		recipeIndex(recipe.element, ingredient0.element, ingredient1.element),
+		treeFromRecipe(ingredient0, graph),
+		treeFromRecipe(ingredient1, graph),
+	)
+}
@@ -0,0 +1,141 @@
+package algorithm
+
+import (
+	"backend/search"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	ViolationEmptyTree      = "empty_tree"       // The tree has no root
+	ViolationRoot           = "wrong_root"       // The root is not the target of the tree
+	ViolationUnknownElement = "unknown_element"  // No element has this name
+	ViolationElementID      = "element_id"       // The ID is not the one of the named element
+	ViolationIngredients    = "ingredient_count" // A crafted element does not have two ingredients
+	ViolationUnknownRecipe  = "unknown_recipe"   // The ingredients are not a recipe of the element
+	ViolationRecipeIndex    = "recipe_index"     // Recipe does not point to the recipe of the ingredients
+	ViolationLeaf           = "leaf"             // A leaf is neither a base element nor owned
+	ViolationCycle          = "cycle"            // An element is an ingredient of itself
+)
+
+// Reason a recipe tree is not valid. Path is a JSON pointer to the node in
+// the tree, e.g. /root/ingredients/0
+type TreeViolation struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	Element string `json:"element,omitempty"`
+	Message string `json:"message"`
+}
+
+func (v TreeViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// Checks a tree in the canonical format against graph: the root is the
+// target, every recipe is one of ElementNode.Recipes, every leaf is a base
+// element or one of owned, and no element is its own ingredient. Depth,
+// tier and crafts are derived and not checked. Empty when the tree is valid
+func ValidateTree(graph *search.RecipeGraph, tree *RecipeTree, owned []*search.ElementNode) []TreeViolation {
+	violations := make([]TreeViolation, 0)
+	if tree == nil || tree.Root == nil {
+		return append(violations, TreeViolation{ViolationEmptyTree, "/root", "", "the tree has no root"})
+	}
+	if tree.Target != "" && tree.Root.Name != tree.Target {
+		violations = append(violations, TreeViolation{ViolationRoot, "/root", tree.Root.Name,
+			fmt.Sprintf("the root is %s, not the target %s", tree.Root.Name, tree.Target)})
+	}
+
+	v := &treeValidator{graph: graph, owned: owned, violations: violations}
+	v.node(tree.Root, "/root", nil)
+	return v.violations
+}
+
+type treeValidator struct {
+	graph      *search.RecipeGraph
+	owned      []*search.ElementNode
+	violations []TreeViolation
+}
+
+func (v *treeValidator) report(kind, path, element, format string, args ...any) {
+	v.violations = append(v.violations, TreeViolation{kind, path, element, fmt.Sprintf(format, args...)})
+}
+
+// ancestors are the elements from the root down to the parent of node
+func (v *treeValidator) node(node *TreeNode, path string, ancestors []*search.ElementNode) {
+	if node == nil {
+		v.report(ViolationUnknownElement, path, "", "missing node")
+		return
+	}
+	element, err := search.GetElementByName(v.graph, node.Name)
+	if err != nil || element == search.GetRoot(v.graph) {
+		v.report(ViolationUnknownElement, path, node.Name, "unknown element '%s'", node.Name)
+		return
+	}
+	if node.ID != 0 && node.ID != element.ID {
+		v.report(ViolationElementID, path, node.Name, "%s has ID %d, not %d", element.Name, element.ID, node.ID)
+	}
+	if slices.Contains(ancestors, element) {
+		v.report(ViolationCycle, path, node.Name, "%s is needed to craft itself", element.Name)
+		return
+	}
+
+	if len(node.Ingredients) == 0 {
+		if !slices.Contains(v.graph.BaseElements, element) && !slices.Contains(v.owned, element) {
+			v.report(ViolationLeaf, path, node.Name, "%s is a leaf but is neither a base element nor owned", element.Name)
+		}
+		return
+	}
+	if len(node.Ingredients) != 2 {
+		v.report(ViolationIngredients, path, node.Name, "%s has %d ingredients, a recipe has 2", element.Name, len(node.Ingredients))
+	} else if node.Ingredients[0] != nil && node.Ingredients[1] != nil {
+		v.recipe(node, element, path)
+	}
+
+	ancestors = append(ancestors, element)
+	for i, ingredient := range node.Ingredients {
+		v.node(ingredient, fmt.Sprintf("%s/ingredients/%d", path, i), ancestors[:len(ancestors):len(ancestors)])
+	}
+}
+
+func (v *treeValidator) recipe(node *TreeNode, element *search.ElementNode, path string) {
+	name0, name1 := node.Ingredients[0].Name, node.Ingredients[1].Name
+	index := recipeIndexByName(element, name0, name1)
+	if index < 0 {
+		if slices.Contains(v.graph.BaseElements, element) {
+			v.report(ViolationUnknownRecipe, path, node.Name, "%s is a base element and cannot be crafted", element.Name)
+		} else {
+			v.report(ViolationUnknownRecipe, path, node.Name, "%s + %s is not a recipe of %s", name0, name1, element.Name)
+		}
+		return
+	}
+	if node.Recipe == index {
+		return
+	}
+	// The same pair may be listed twice, any of its indices is fine
+	if node.Recipe >= 0 && node.Recipe < len(element.Recipes) {
+		recipe := element.Recipes[node.Recipe]
+		if sameIngredients(recipe, name0, name1) {
+			return
+		}
+		v.report(ViolationRecipeIndex, path, node.Name, "recipe %d of %s is %s + %s, not %s + %s",
+			node.Recipe, element.Name, recipe[0].Name, recipe[1].Name, name0, name1)
+		return
+	}
+	v.report(ViolationRecipeIndex, path, node.Name, "recipe %d of %s does not exist, %s + %s is recipe %d",
+		node.Recipe, element.Name, name0, name1, index)
+}
+
+func sameIngredients(recipe []*search.ElementNode, name0, name1 string) bool {
+	return recipe[0].Name == name0 && recipe[1].Name == name1 ||
+		recipe[0].Name == name1 && recipe[1].Name == name0
+}
+
+// Violations joined into one message, for tests and logs
+func FormatViolations(violations []TreeViolation) string {
+	lines := make([]string, len(violations))
+	for i, violation := range violations {
+		lines[i] = violation.String()
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,76 @@
+package algorithm_test
+
+import (
+	"backend/algorithm"
+	"backend/search"
+	"backend/search/graphtest"
+	"testing"
+)
+
+// Tree of name with the given ingredients, a leaf without any
+func node(name string, ingredients ...*algorithm.TreeNode) *algorithm.TreeNode {
+	recipe := -1
+	if len(ingredients) > 0 {
+		recipe = 0
+	}
+	if ingredients == nil {
+		ingredients = []*algorithm.TreeNode{}
+	}
+	return &algorithm.TreeNode{Name: name, Recipe: recipe, Ingredients: ingredients}
+}
+
+func TestValidateTree(t *testing.T) {
+	graph := graphtest.Diamond().Graph(t)
+	lava, _ := search.GetElementByName(graph, "Lava")
+
+	tests := []struct {
+		name  string
+		tree  *algorithm.RecipeTree
+		owned []*search.ElementNode
+		want  []algorithm.TreeViolation // Only Kind and Path are compared
+	}{
+		{"valid", &algorithm.RecipeTree{Target: "Sand", Root: node("Sand",
+			node("Stone", node("Lava", node("Earth"), node("Fire")), node("Air")), node("Air"))}, nil, nil},
+		{"second recipe in the other order", &algorithm.RecipeTree{Target: "Stone", Root: &algorithm.TreeNode{Name: "Stone", Recipe: 1,
+			Ingredients: []*algorithm.TreeNode{node("Pressure", node("Air"), node("Air")), node("Earth")}}}, nil, nil},
+		{"owned leaf", &algorithm.RecipeTree{Target: "Stone", Root: node("Stone", node("Lava"), node("Air"))},
+			[]*search.ElementNode{lava}, nil},
+		{"no root", &algorithm.RecipeTree{Target: "Stone"}, nil, []algorithm.TreeViolation{
+			{Kind: algorithm.ViolationEmptyTree, Path: "/root"}}},
+		{"wrong root", &algorithm.RecipeTree{Target: "Sand", Root: node("Lava", node("Earth"), node("Fire"))}, nil, []algorithm.TreeViolation{
+			{Kind: algorithm.ViolationRoot, Path: "/root"}}},
+		{"crafted leaf", &algorithm.RecipeTree{Target: "Stone", Root: node("Stone", node("Lava"), node("Air"))}, nil, []algorithm.TreeViolation{
+			{Kind: algorithm.ViolationLeaf, Path: "/root/ingredients/0"}}},
+		{"unknown recipe", &algorithm.RecipeTree{Target: "Lava", Root: node("Lava", node("Air"), node("Fire"))}, nil, []algorithm.TreeViolation{
+			{Kind: algorithm.ViolationUnknownRecipe, Path: "/root"}}},
+		{"crafted base element", &algorithm.RecipeTree{Target: "Air", Root: node("Air", node("Earth"), node("Fire"))}, nil, []algorithm.TreeViolation{
+			{Kind: algorithm.ViolationUnknownRecipe, Path: "/root"}}},
+		{"wrong recipe index", &algorithm.RecipeTree{Target: "Stone", Root: node("Stone", node("Earth"), node("Pressure", node("Air"), node("Air")))}, nil, []algorithm.TreeViolation{
+			{Kind: algorithm.ViolationRecipeIndex, Path: "/root"}}},
+		{"one ingredient", &algorithm.RecipeTree{Target: "Lava", Root: node("Lava", node("Earth"))}, nil, []algorithm.TreeViolation{
+			{Kind: algorithm.ViolationIngredients, Path: "/root"}}},
+		{"unknown element", &algorithm.RecipeTree{Target: "Lava", Root: node("Lava", node("Earth"), node("Plasma"))}, nil, []algorithm.TreeViolation{
+			{Kind: algorithm.ViolationUnknownRecipe, Path: "/root"},
+			{Kind: algorithm.ViolationUnknownElement, Path: "/root/ingredients/1"}}},
+		{"wrong id", &algorithm.RecipeTree{Target: "Lava", Root: &algorithm.TreeNode{ID: 1, Name: "Lava", Recipe: 0,
+			Ingredients: []*algorithm.TreeNode{node("Earth"), node("Fire")}}}, nil, []algorithm.TreeViolation{
+			{Kind: algorithm.ViolationElementID, Path: "/root"}}},
+		{"cycle", &algorithm.RecipeTree{Target: "Sand", Root: node("Sand",
+			node("Stone", node("Sand", node("Stone"), node("Air")), node("Air")), node("Air"))}, nil, []algorithm.TreeViolation{
+			{Kind: algorithm.ViolationUnknownRecipe, Path: "/root/ingredients/0"},
+			{Kind: algorithm.ViolationCycle, Path: "/root/ingredients/0/ingredients/0"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := algorithm.ValidateTree(graph, test.tree, test.owned)
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d violations, want %d:\n%s", len(got), len(test.want), algorithm.FormatViolations(got))
+			}
+			for i, violation := range got {
+				if violation.Kind != test.want[i].Kind || violation.Path != test.want[i].Path {
+					t.Errorf("violation %d is %s at %s, want %s at %s", i, violation.Kind, violation.Path, test.want[i].Kind, test.want[i].Path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/analytics"
+	"backend/search"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/analytics?top=20
+func handleAnalytics(datasets *search.GraphStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request AnalyticsRequest
+		if err := c.ShouldBindQuery(&request); err != nil {
+			c.Error(invalidParameter("Invalid query: %v", err))
+			return
+		}
+
+		report := analytics.Analyze(datasets.Current().Graph, request.Top)
+		c.JSON(http.StatusOK, AnalyticsResponse{
+			Error: false,
+			Data:  report,
+		})
+	}
+}
+
+// Importance of every element of the current dataset, computed once per
+// dataset
+type importanceCache struct {
+	mu      sync.Mutex
+	graph   *search.RecipeGraph
+	entries []analytics.ElementImportance
+}
+
+func (c *importanceCache) get(graph *search.RecipeGraph) []analytics.ElementImportance {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.graph != graph {
+		c.entries = analytics.Importance(graph)
+		c.graph = graph
+	}
+	return c.entries
+}
+
+// GET /api/leaderboard?sort=dominates&top=20
+func handleLeaderboard(datasets *search.GraphStore) gin.HandlerFunc {
+	cache := &importanceCache{}
+	return func(c *gin.Context) {
+		var request LeaderboardRequest
+		if err := c.ShouldBindQuery(&request); err != nil {
+			c.Error(invalidParameter("Invalid query: %v", err))
+			return
+		}
+
+		entries := cache.get(datasets.Current().Graph)
+		c.JSON(http.StatusOK, LeaderboardResponse{
+			Error: false,
+			Data: LeaderboardData{
+				Sort:     request.Sort,
+				Elements: analytics.Leaderboard(entries, request.Sort, request.Top, request.IncludeBase),
+			},
+		})
+	}
+}
+
+// GET /api/dominators?element=Human&check=Life
+func handleDominators(datasets *search.GraphStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request DominatorsRequest
+		if err := c.ShouldBindQuery(&request); err != nil {
+			c.Error(invalidParameter("Invalid query: %v", err))
+			return
+		}
+		graph := datasets.Current().Graph
+		target, err := search.GetElementByName(graph, request.Element)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if slices.Contains(graph.BaseElements, target) {
+			c.Error(algorithm.ErrBaseElement)
+			return
+		}
+
+		dominators := analytics.ComputeDominators(graph)
+		if !dominators.Craftable(target) {
+			c.Error(fmt.Errorf("%w for %s", algorithm.ErrUnreachable, target.Name))
+			return
+		}
+		data := DominatorsData{
+			Element:      target.Name,
+			Intermediate: make([]DominatorElement, 0),
+			Base:         make([]string, 0),
+		}
+		for _, element := range dominators.Of(target) {
+			switch {
+			case element == target:
+			case slices.Contains(graph.BaseElements, element):
+				data.Base = append(data.Base, element.Name)
+			default:
+				data.Intermediate = append(data.Intermediate, DominatorElement{element.Name, element.Tier})
+			}
+		}
+
+		if request.Check != "" {
+			checked, err := search.GetElementByName(graph, request.Check)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			data.Check = &DominatorCheck{
+				Element:        checked.Name,
+				Unavoidable:    dominators.Dominates(checked, target),
+				Counterexample: analytics.TreeAvoiding(graph, target, checked),
+			}
+		}
+		c.JSON(http.StatusOK, DominatorsResponse{
+			Error: false,
+			Data:  data,
+		})
+	}
+}
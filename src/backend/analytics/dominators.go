@@ -0,0 +1,174 @@
+package analytics
+
+import (
+	"backend/algorithm"
+	"backend/search"
+	"cmp"
+	"math/bits"
+	"slices"
+)
+
+// Set of elements by ID
+type elementSet []uint64
+
+func newElementSet(size int) elementSet { return make(elementSet, (size+63)/64) }
+
+func (s elementSet) add(id int)      { s[id/64] |= 1 << (id % 64) }
+func (s elementSet) has(id int) bool { return s[id/64]&(1<<(id%64)) != 0 }
+
+func (s elementSet) count() int {
+	n := 0
+	for _, word := range s {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
+
+// Elements in order of tier, so that the ingredients of every usable recipe
+// come before its result
+func tierOrder(graph *search.RecipeGraph) []*search.ElementNode {
+	order := slices.Clone(graph.Elements[1:])
+	slices.SortStableFunc(order, func(a, b *search.ElementNode) int { return cmp.Compare(a.Tier, b.Tier) })
+	return order
+}
+
+func isBase(graph *search.RecipeGraph, element *search.ElementNode) bool {
+	return slices.Contains(graph.BaseElements, element)
+}
+
+// Recipes of element usable by the search algorithms whose ingredients can
+// be crafted themselves
+func craftableRecipes(element *search.ElementNode, craftable elementSet) [][]*search.ElementNode {
+	recipes := make([][]*search.ElementNode, 0, len(element.Recipes))
+	for _, recipe := range element.Recipes {
+		if algorithm.IsUsableRecipe(element, recipe) && craftable.has(recipe[0].ID) && craftable.has(recipe[1].ID) {
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes
+}
+
+// Elements that appear in every recipe tree of an element, the element and
+// its base elements included. Trees only use the recipes the search
+// algorithms use, which always have ingredients of a lower tier, so the
+// recipe graph is acyclic and the sets are computed in one pass:
+//
+//	dom(base) = {base}
+//	dom(x)    = {x} ∪ ⋂ over the recipes a + b of x of (dom(a) ∪ dom(b))
+type Dominators struct {
+	graph *search.RecipeGraph
+	sets  []elementSet // By element ID, nil when the element has no recipe tree
+}
+
+func ComputeDominators(graph *search.RecipeGraph) *Dominators {
+	d := &Dominators{graph: graph, sets: make([]elementSet, len(graph.Elements))}
+	craftable := newElementSet(len(graph.Elements))
+
+	for _, element := range tierOrder(graph) {
+		set := newElementSet(len(graph.Elements))
+		if isBase(graph, element) {
+			set.add(element.ID)
+			d.sets[element.ID] = set
+			craftable.add(element.ID)
+			continue
+		}
+
+		recipes := craftableRecipes(element, craftable)
+		if len(recipes) == 0 {
+			continue
+		}
+		for i := range set {
+			set[i] = ^uint64(0)
+		}
+		for _, recipe := range recipes {
+			a, b := d.sets[recipe[0].ID], d.sets[recipe[1].ID]
+			for i := range set {
+				set[i] &= a[i] | b[i]
+			}
+		}
+		set.add(element.ID)
+		d.sets[element.ID] = set
+		craftable.add(element.ID)
+	}
+	return d
+}
+
+// Whether element has at least one recipe tree
+func (d *Dominators) Craftable(element *search.ElementNode) bool {
+	return d.sets[element.ID] != nil
+}
+
+// Whether every recipe tree of target contains element
+func (d *Dominators) Dominates(element, target *search.ElementNode) bool {
+	set := d.sets[target.ID]
+	return set != nil && set.has(element.ID)
+}
+
+// Elements in every recipe tree of target, by tier then name. Nil when target
+// has no recipe tree
+func (d *Dominators) Of(target *search.ElementNode) []*search.ElementNode {
+	set := d.sets[target.ID]
+	if set == nil {
+		return nil
+	}
+	elements := make([]*search.ElementNode, 0, set.count())
+	for _, element := range d.graph.Elements[1:] {
+		if set.has(element.ID) {
+			elements = append(elements, element)
+		}
+	}
+	slices.SortFunc(elements, func(a, b *search.ElementNode) int {
+		if a.Tier != b.Tier {
+			return cmp.Compare(a.Tier, b.Tier)
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return elements
+}
+
+// Recipe tree of target with the fewest crafts that does not contain avoid,
+// nil when every tree of target contains it. Proves that avoid does not
+// dominate target
+func TreeAvoiding(graph *search.RecipeGraph, target, avoid *search.ElementNode) *algorithm.RecipeTree {
+	if target == avoid {
+		return nil
+	}
+	crafts := make([]int, len(graph.Elements))
+	best := make([]int, len(graph.Elements)) // Index of the chosen recipe
+	craftable := newElementSet(len(graph.Elements))
+
+	for _, element := range tierOrder(graph) {
+		if element == avoid {
+			continue
+		}
+		if isBase(graph, element) {
+			craftable.add(element.ID)
+			continue
+		}
+		best[element.ID] = -1
+		for i, recipe := range element.Recipes {
+			if !algorithm.IsUsableRecipe(element, recipe) || !craftable.has(recipe[0].ID) || !craftable.has(recipe[1].ID) {
+				continue
+			}
+			if cost := 1 + crafts[recipe[0].ID] + crafts[recipe[1].ID]; best[element.ID] < 0 || cost < crafts[element.ID] {
+				best[element.ID], crafts[element.ID] = i, cost
+			}
+		}
+		if best[element.ID] >= 0 {
+			craftable.add(element.ID)
+		}
+	}
+	if !craftable.has(target.ID) {
+		return nil
+	}
+
+	var build func(*search.ElementNode) *algorithm.TreeNode
+	build = func(element *search.ElementNode) *algorithm.TreeNode {
+		if isBase(graph, element) {
+			return algorithm.NewLeaf(element)
+		}
+		recipe := element.Recipes[best[element.ID]]
+		return algorithm.NewCraft(element, best[element.ID], build(recipe[0]), build(recipe[1]))
+	}
+	return algorithm.NewRecipeTree(build(target))
+}
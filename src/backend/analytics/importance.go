@@ -0,0 +1,173 @@
+package analytics
+
+import (
+	"backend/search"
+	"cmp"
+	"slices"
+)
+
+// How essential an element is to the other elements. Targets are the
+// elements other than itself and the base elements that have a recipe tree
+type ElementImportance struct {
+	Element      string  `json:"element"`
+	Tier         int     `json:"tier"`
+	Base         bool    `json:"base"`
+	Dominates    int     `json:"dominates" description:"Targets whose every recipe tree contains the element"`
+	MinimalTrees int     `json:"minimalTrees" description:"Targets whose tree with the fewest crafts contains the element"`
+	Centrality   float64 `json:"centrality" description:"Share of the targets a random recipe tree passes through the element for, 0 to 1"`
+	Descendants  int     `json:"descendants" description:"Elements crafted from the element, directly or not"`
+}
+
+// Orders of the leaderboard
+var ImportanceKeys = []string{"dominates", "minimalTrees", "centrality", "descendants"}
+
+// Importance of every element, by element ID
+func Importance(graph *search.RecipeGraph) []ElementImportance {
+	dominators := ComputeDominators(graph)
+	minimal := minimalTrees(graph, dominators)
+	targets := make([]*search.ElementNode, 0, len(graph.Elements))
+	for _, element := range graph.Elements[1:] {
+		if dominators.Craftable(element) && !isBase(graph, element) {
+			targets = append(targets, element)
+		}
+	}
+
+	entries := make([]ElementImportance, 0, len(graph.Elements)-1)
+	for _, element := range graph.Elements[1:] {
+		entry := ElementImportance{
+			Element:     element.Name,
+			Tier:        element.Tier,
+			Base:        isBase(graph, element),
+			Descendants: descendants(element),
+		}
+		others := 0
+		for _, target := range targets {
+			if target == element {
+				continue
+			}
+			others++
+			if dominators.Dominates(element, target) {
+				entry.Dominates++
+			}
+			if minimal[target.ID].has(element.ID) {
+				entry.MinimalTrees++
+			}
+		}
+		if others > 0 && dominators.Craftable(element) {
+			entry.Centrality = passThrough(graph, dominators, element) / float64(others)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Elements of the tree with the fewest crafts of every target, by target ID.
+// Ties go to the first recipe
+func minimalTrees(graph *search.RecipeGraph, dominators *Dominators) []elementSet {
+	crafts := make([]int, len(graph.Elements))
+	trees := make([]elementSet, len(graph.Elements))
+	craftable := newElementSet(len(graph.Elements))
+
+	for _, element := range tierOrder(graph) {
+		if !dominators.Craftable(element) {
+			continue
+		}
+		tree := newElementSet(len(graph.Elements))
+		tree.add(element.ID)
+		if !isBase(graph, element) {
+			var best []*search.ElementNode
+			for _, recipe := range craftableRecipes(element, craftable) {
+				if cost := 1 + crafts[recipe[0].ID] + crafts[recipe[1].ID]; best == nil || cost < crafts[element.ID] {
+					best, crafts[element.ID] = recipe, cost
+				}
+			}
+			for i := range tree {
+				tree[i] |= trees[best[0].ID][i] | trees[best[1].ID][i]
+			}
+		}
+		trees[element.ID] = tree
+		craftable.add(element.ID)
+	}
+	return trees
+}
+
+// Expected number of other targets whose tree contains element, when every
+// occurrence of an element in a tree is crafted with one of its recipes
+// picked at random. Occurrences pick independently, so for a recipe a + b
+// the chance that neither subtree contains element is (1-p(a))(1-p(b))
+func passThrough(graph *search.RecipeGraph, dominators *Dominators, element *search.ElementNode) float64 {
+	p := make([]float64, len(graph.Elements))
+	craftable := newElementSet(len(graph.Elements))
+	total := 0.0
+
+	for _, target := range tierOrder(graph) {
+		if !dominators.Craftable(target) {
+			continue
+		}
+		craftable.add(target.ID)
+		if target == element {
+			p[target.ID] = 1
+			continue
+		}
+		if isBase(graph, target) {
+			continue
+		}
+		recipes := craftableRecipes(target, craftable)
+		for _, recipe := range recipes {
+			p[target.ID] += 1 - (1-p[recipe[0].ID])*(1-p[recipe[1].ID])
+		}
+		p[target.ID] /= float64(len(recipes))
+		total += p[target.ID]
+	}
+	return total
+}
+
+// Number of elements reachable through Children
+func descendants(element *search.ElementNode) int {
+	seen := map[*search.ElementNode]bool{element: true}
+	queue := []*search.ElementNode{element}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, child := range node.Children {
+			if !seen[child] {
+				seen[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return len(seen) - 1
+}
+
+// Entries sorted by key, highest first with ties by name. Base elements are
+// left out unless includeBase
+func Leaderboard(entries []ElementImportance, key string, top int, includeBase bool) []ElementImportance {
+	value := func(entry ElementImportance) float64 {
+		switch key {
+		case "minimalTrees":
+			return float64(entry.MinimalTrees)
+		case "centrality":
+			return entry.Centrality
+		case "descendants":
+			return float64(entry.Descendants)
+		}
+		return float64(entry.Dominates)
+	}
+
+	board := make([]ElementImportance, 0, len(entries))
+	for _, entry := range entries {
+		if includeBase || !entry.Base {
+			board = append(board, entry)
+		}
+	}
+	slices.SortFunc(board, func(a, b ElementImportance) int {
+		if c := cmp.Compare(value(b), value(a)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Element, b.Element)
+	})
+	if top > 0 && len(board) > top {
+		board = board[:top]
+	}
+	return board
+}
@@ -0,0 +1,178 @@
+// Package analytics reports on the structure of a RecipeGraph: what can be
+// crafted under the rules the search algorithms apply, and which elements
+// and recipes matter most
+package analytics
+
+import (
+	"backend/algorithm"
+	"backend/search"
+	"cmp"
+	"slices"
+)
+
+// Which recipes count as craftable
+type RuleSet struct {
+	Name     string `json:"name"`
+	Tier     bool   `json:"tier"`     // Ingredients must have a lower tier than the result
+	NoRecipe bool   `json:"noRecipe"` // Ingredients must be craftable or base elements
+}
+
+var RuleSets = []RuleSet{
+	{"none", false, false},
+	{"tier", true, false},
+	{"no_recipe", false, true},
+	{"search", true, true}, // What the search algorithms use
+}
+
+// Whether rules allow the recipe of node. Base elements are never crafted
+func (rules RuleSet) Allows(node *search.ElementNode, recipe []*search.ElementNode) bool {
+	if isRootRecipe(recipe) {
+		return false
+	}
+	if rules.Tier && algorithm.BreaksTierRule(node, recipe) {
+		return false
+	}
+	return !rules.NoRecipe || !algorithm.UsesUncraftable(recipe)
+}
+
+// Recipe of base elements, made of the root sentinel
+func isRootRecipe(recipe []*search.ElementNode) bool {
+	return recipe[0].ID == 0 || recipe[1].ID == 0
+}
+
+// Elements craftable from the base elements with the recipes rules allows,
+// base elements included
+func Reachable(graph *search.RecipeGraph, rules RuleSet) map[*search.ElementNode]bool {
+	reached := make(map[*search.ElementNode]bool, len(graph.Elements))
+	for _, base := range graph.BaseElements {
+		if base != nil {
+			reached[base] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, element := range graph.Elements[1:] {
+			if reached[element] {
+				continue
+			}
+			for _, recipe := range element.Recipes {
+				if reached[recipe[0]] && reached[recipe[1]] && rules.Allows(element, recipe) {
+					reached[element] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return reached
+}
+
+type Report struct {
+	Elements      int               `json:"elements"`
+	Recipes       int               `json:"recipes"`
+	Unreachable   []UnreachableSet  `json:"unreachable"` // One per rule set
+	Terminal      []string          `json:"terminal"`    // Elements that are no ingredient of anything
+	InDegree      []DegreeRank      `json:"inDegree"`    // Elements with the most recipes
+	OutDegree     []DegreeRank      `json:"outDegree"`   // Elements used in the most recipes
+	TierDiscarded []DiscardedRecipe `json:"tierDiscarded"`
+	Discarded     map[string]int    `json:"discarded"` // Recipes the search skips, by algorithm.Prune* reason
+}
+
+type UnreachableSet struct {
+	Rules    RuleSet  `json:"rules"`
+	Elements []string `json:"elements"`
+}
+
+type DegreeRank struct {
+	Element string `json:"element"`
+	Tier    int    `json:"tier"`
+	Degree  int    `json:"degree"`
+}
+
+// A recipe with an ingredient that does not have a lower tier
+type DiscardedRecipe struct {
+	Result          string    `json:"result"`
+	ResultTier      int       `json:"resultTier"`
+	Ingredients     [2]string `json:"ingredients"`
+	IngredientTiers [2]int    `json:"ingredientTiers"`
+}
+
+// Builds the report. The degree rankings keep the top elements only
+func Analyze(graph *search.RecipeGraph, top int) *Report {
+	report := &Report{
+		Elements:      len(graph.Elements) - 1,
+		Unreachable:   make([]UnreachableSet, 0, len(RuleSets)),
+		Terminal:      make([]string, 0),
+		TierDiscarded: make([]DiscardedRecipe, 0),
+		Discarded:     map[string]int{algorithm.PruneTier: 0, algorithm.PruneNoRecipe: 0},
+	}
+
+	for _, rules := range RuleSets {
+		reached := Reachable(graph, rules)
+		set := UnreachableSet{Rules: rules, Elements: make([]string, 0)}
+		for _, element := range graph.Elements[1:] {
+			if !reached[element] {
+				set.Elements = append(set.Elements, element.Name)
+			}
+		}
+		slices.Sort(set.Elements)
+		report.Unreachable = append(report.Unreachable, set)
+	}
+
+	inDegree := make([]DegreeRank, 0, report.Elements)
+	outDegree := make(map[*search.ElementNode]int, report.Elements)
+	for _, element := range graph.Elements[1:] {
+		if len(element.Children) == 0 {
+			report.Terminal = append(report.Terminal, element.Name)
+		}
+
+		recipes := 0
+		for _, recipe := range element.Recipes {
+			if isRootRecipe(recipe) {
+				continue
+			}
+			recipes++
+			outDegree[recipe[0]]++
+			if recipe[1] != recipe[0] {
+				outDegree[recipe[1]]++
+			}
+
+			if algorithm.BreaksTierRule(element, recipe) {
+				report.Discarded[algorithm.PruneTier]++
+				report.TierDiscarded = append(report.TierDiscarded, DiscardedRecipe{
+					Result:          element.Name,
+					ResultTier:      element.Tier,
+					Ingredients:     [2]string{recipe[0].Name, recipe[1].Name},
+					IngredientTiers: [2]int{recipe[0].Tier, recipe[1].Tier},
+				})
+			} else if algorithm.UsesUncraftable(recipe) {
+				report.Discarded[algorithm.PruneNoRecipe]++
+			}
+		}
+		report.Recipes += recipes
+		inDegree = append(inDegree, DegreeRank{element.Name, element.Tier, recipes})
+	}
+	slices.Sort(report.Terminal)
+
+	report.InDegree = topRanks(inDegree, top)
+	ranks := make([]DegreeRank, 0, len(outDegree))
+	for element, degree := range outDegree {
+		ranks = append(ranks, DegreeRank{element.Name, element.Tier, degree})
+	}
+	report.OutDegree = topRanks(ranks, top)
+	return report
+}
+
+// Highest degrees first, ties by name
+func topRanks(ranks []DegreeRank, top int) []DegreeRank {
+	slices.SortFunc(ranks, func(a, b DegreeRank) int {
+		if a.Degree != b.Degree {
+			return cmp.Compare(b.Degree, a.Degree)
+		}
+		return cmp.Compare(a.Element, b.Element)
+	})
+	if top > 0 && len(ranks) > top {
+		ranks = ranks[:top]
+	}
+	return ranks
+}
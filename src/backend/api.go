@@ -0,0 +1,184 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/analytics"
+	"time"
+)
+
+// Query of /api/recipe, /api/recipes and /api/recipes/stream. Bound once
+// validateQuery checked it against the OpenAPI parameters, which also fill
+// in the defaults
+type SearchRequest struct {
+	Element  string `form:"element"`
+	Algo     string `form:"algo"`
+	Max      int    `form:"max"` // Ignored by /api/recipe, which always finds one recipe
+	MaxDepth int    `form:"maxDepth"`
+	Format   string `form:"format"` // json or one of render.Formats()
+}
+
+// Query of /api/graph
+type GraphRequest struct {
+	Format  string `form:"format"`
+	Element string `form:"element"` // Whole graph when empty
+	Radius  int    `form:"radius"`
+}
+
+// Query of /api/analytics
+type AnalyticsRequest struct {
+	Top int `form:"top"` // Length of the degree rankings
+}
+
+// Query of /api/leaderboard
+type LeaderboardRequest struct {
+	Sort        string `form:"sort"` // One of analytics.ImportanceKeys
+	Top         int    `form:"top"`
+	IncludeBase bool   `form:"includeBase"`
+}
+
+// Query of /api/dominators
+type DominatorsRequest struct {
+	Element string `form:"element"`
+	Check   string `form:"check"` // Whether this element is in every tree of Element
+}
+
+// Query of /api/compare
+type CompareRequest struct {
+	Element  string `form:"element"`
+	Max      int    `form:"max"`
+	MaxDepth int    `form:"maxDepth"`
+}
+
+// Body of POST /api/validate
+type ValidateRequest struct {
+	Tree  *algorithm.RecipeTree `json:"tree"`
+	Owned []string              `json:"owned"` // Elements that may be leaves besides the base ones
+}
+
+// Every failed request
+type ErrorResponse struct {
+	Error   bool   `json:"error"` // Always true
+	Type    string `json:"type" description:"missing_parameter, invalid_parameter, invalid_algorithm, element_not_found, unreachable, base_element (dominators only), timeout, dataset_not_ready, forbidden, invalid_body, reload_failed or internal_error"`
+	Message string `json:"message"`
+}
+
+type SearchResponse struct {
+	Error bool       `json:"error"` // Always false
+	Data  SearchData `json:"data"`
+}
+
+type SearchData struct {
+	Algo            string                  `json:"algo"`
+	Element         string                  `json:"element"`
+	Paths           []*algorithm.RecipeTree `json:"paths"`
+	VisitedNodes    int                     `json:"visitedNodes"`
+	Duplicates      *int                    `json:"duplicates,omitempty" description:"Structurally equal trees skipped, they do not count towards max. /api/recipes only"`
+	VisitedForward  *int                    `json:"visitedForward,omitempty" description:"Bidirectional only"`
+	VisitedBackward *int                    `json:"visitedBackward,omitempty" description:"Bidirectional only"`
+	Depth           *int                    `json:"depth,omitempty" description:"IDDFS only, the depth limit the trees were found at"`
+	Stats           algorithm.SearchStats   `json:"stats"`
+}
+
+// /healthz and /readyz
+type HealthResponse struct {
+	Status  string        `json:"status" description:"ok for /healthz. ready, loading, load_failed or shutting_down for /readyz"`
+	Uptime  string        `json:"uptime,omitempty" description:"/healthz only"`
+	Dataset DatasetStatus `json:"dataset"`
+}
+
+type DatasetStatus struct {
+	Loaded   bool       `json:"loaded"`
+	Version  string     `json:"version,omitempty"`
+	Elements int        `json:"elements,omitempty"`
+	LoadedAt *time.Time `json:"loadedAt,omitempty"`
+	Error    string     `json:"error,omitempty" description:"Why the last load or reload failed"`
+}
+
+type ReloadResponse struct {
+	Error bool       `json:"error"` // Always false
+	Data  ReloadData `json:"data"`
+}
+
+type ReloadData struct {
+	Version         string    `json:"version"`
+	Elements        int       `json:"elements"`
+	LoadedAt        time.Time `json:"loadedAt"`
+	Changed         bool      `json:"changed"`
+	PreviousVersion string    `json:"previousVersion,omitempty"`
+}
+
+type AnalyticsResponse struct {
+	Error bool              `json:"error"` // Always false
+	Data  *analytics.Report `json:"data"`
+}
+
+type LeaderboardResponse struct {
+	Error bool            `json:"error"` // Always false
+	Data  LeaderboardData `json:"data"`
+}
+
+type LeaderboardData struct {
+	Sort     string                        `json:"sort"`
+	Elements []analytics.ElementImportance `json:"elements"`
+}
+
+type DominatorsResponse struct {
+	Error bool           `json:"error"` // Always false
+	Data  DominatorsData `json:"data"`
+}
+
+// Elements in every recipe tree of Element, using the recipes the search
+// algorithms use
+type DominatorsData struct {
+	Element      string             `json:"element"`
+	Intermediate []DominatorElement `json:"intermediate" description:"Crafted elements every tree goes through, by tier"`
+	Base         []string           `json:"base" description:"Base elements every tree needs"`
+	Check        *DominatorCheck    `json:"check,omitempty"`
+}
+
+type DominatorElement struct {
+	Name string `json:"name"`
+	Tier int    `json:"tier"`
+}
+
+type DominatorCheck struct {
+	Element        string                `json:"element"`
+	Unavoidable    bool                  `json:"unavoidable"`
+	Counterexample *algorithm.RecipeTree `json:"counterexample,omitempty" description:"A tree without the element, when it is avoidable"`
+}
+
+type ValidateResponse struct {
+	Error bool         `json:"error"` // Always false
+	Data  ValidateData `json:"data"`
+}
+
+// Whether a submitted tree is a valid recipe tree of its target
+type ValidateData struct {
+	Valid      bool                      `json:"valid"`
+	Target     string                    `json:"target"`
+	Violations []algorithm.TreeViolation `json:"violations" description:"Empty when the tree is valid"`
+}
+
+type CompareResponse struct {
+	Error bool        `json:"error"` // Always false
+	Data  CompareData `json:"data"`
+}
+
+// Every algorithm run on the same element with the same options
+type CompareData struct {
+	Element  string                `json:"element"`
+	Max      int                   `json:"max"`
+	MaxDepth int                   `json:"maxDepth"`
+	Results  []AlgorithmComparison `json:"results" description:"In the order of the algo enum"`
+}
+
+type AlgorithmComparison struct {
+	Algo         string  `json:"algo"`
+	DurationMs   float64 `json:"durationMs"`
+	VisitedNodes int     `json:"visitedNodes"`
+	Trees        int     `json:"trees" description:"Number of trees found, at most max"`
+	Crafts       int     `json:"crafts" description:"Size of the smallest tree found, in crafts"`
+	Depth        int     `json:"depth" description:"Depth of the smallest tree found"`
+	ErrorType    string  `json:"errorType,omitempty" description:"Type of the error when the search failed, e.g. timeout or unreachable"`
+	Message      string  `json:"message,omitempty"`
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/analytics"
+	"backend/render"
+	"backend/scraping"
+	"backend/search"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+func runScrape(args []string) error {
+	flags, recipes := newFlagSet("scrape")
+	icons := flags.Bool("icons", false, "also download the element icons")
+	wikiURL := flags.String("wiki-url", "", "wiki page listing the elements and their recipes")
+	if positional, err := parseArgs(flags, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+
+	scraping.SetResultPath(*recipes)
+	if *wikiURL != "" {
+		scraping.SetWikiURL(*wikiURL)
+	}
+	return scraping.ScrapeRecipes(*icons)
+}
+
+func runValidate(args []string) error {
+	flags, recipes := newFlagSet("validate")
+	if positional, err := parseArgs(flags, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+
+	entry, err := loadRecipes(*recipes)
+	if err != nil {
+		return err
+	}
+	errorCount := 0
+	issues := scraping.ValidateRecipes(entry)
+	for _, issue := range issues {
+		fmt.Println(issue)
+		if issue.Severity == "error" {
+			errorCount++
+		}
+	}
+	fmt.Printf("%d elements, %d errors, %d warnings\n", len(entry.Element), errorCount, len(issues)-errorCount)
+	if errorCount > 0 {
+		return fmt.Errorf("%s is invalid", *recipes)
+	}
+	return nil
+}
+
+// Flags of the commands running a search
+type searchFlags struct {
+	recipes  *string
+	algo     *string
+	max      *int
+	maxDepth *int
+	format   *string
+	timeout  *time.Duration
+}
+
+func addSearchFlags(flags *flag.FlagSet, recipes *string, defaultMax int, defaultFormat string) *searchFlags {
+	return &searchFlags{
+		recipes:  recipes,
+		algo:     flags.String("algo", "bfs", "search algorithm: "+strings.Join(algorithm.Algorithms(), ", ")),
+		max:      flags.Int("max", defaultMax, "number of recipe trees to find"),
+		maxDepth: flags.Int("max-depth", 0, "depth limit of iddfs, 0 for the tier of the target"),
+		format:   flags.String("format", defaultFormat, "output format: "+strings.Join(formatNames(), ", ")),
+		timeout:  flags.Duration("timeout", 30*time.Second, "give up the search after this long"),
+	}
+}
+
+// Loads the graph and runs the search the flags describe
+func (s *searchFlags) search(element string) (*algorithm.SearchResult, error) {
+	if _, ok := formats[*s.format]; !ok {
+		return nil, fmt.Errorf("format must be one of %s", strings.Join(formatNames(), ", "))
+	}
+	graph, err := loadGraph(*s.recipes)
+	if err != nil {
+		return nil, err
+	}
+	target, err := search.GetElementByName(graph, element)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *s.timeout)
+	defer cancel()
+	return algorithm.Search(ctx, strings.ToLower(*s.algo), target, graph, algorithm.SearchOptions{
+		MaxPaths: *s.max,
+		MaxDepth: *s.maxDepth,
+		Dedup:    *s.max > 1,
+	})
+}
+
+func runSearch(args []string) error {
+	flags, recipes := newFlagSet("search")
+	options := addSearchFlags(flags, recipes, 1, "text")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	element, err := elementName(positional)
+	if err != nil {
+		return err
+	}
+
+	result, err := options.search(element)
+	if err != nil {
+		return err
+	}
+	if err := formats[*options.format].write(os.Stdout, result); err != nil {
+		return err
+	}
+	// Other formats only have the trees, the statistics go to stderr so the
+	// output can still be piped
+	if *options.format != "json" {
+		fmt.Fprintf(os.Stderr, "%s visited %d nodes in %.3f ms\n", result.Algorithm, result.VisitedNodes, result.Stats.DurationMs)
+	}
+	return nil
+}
+
+// Writes every tree to <out>/<element>_001.<ext> and so on, one file per
+// tree so they can be diffed or pasted separately
+func runExport(args []string) error {
+	flags, recipes := newFlagSet("export")
+	options := addSearchFlags(flags, recipes, 5, "json")
+	out := flags.String("out", ".", "directory the files are written to")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	element, err := elementName(positional)
+	if err != nil {
+		return err
+	}
+
+	result, err := options.search(element)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+
+	format := formats[*options.format]
+	prefix := strings.ReplaceAll(strings.ToLower(element), " ", "_")
+	for i, tree := range result.Trees {
+		single := *result
+		single.Trees = []*algorithm.RecipeTree{tree}
+
+		filename := filepath.Join(*out, fmt.Sprintf("%s_%03d.%s", prefix, i+1, format.extension))
+		file, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		err = format.write(file, &single)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", filename, err)
+		}
+		fmt.Println(filename)
+	}
+	return nil
+}
+
+// Writes the whole graph, or the elements around one, for Cytoscape, Gephi
+// or Graphviz
+func runGraph(args []string) error {
+	flags, recipes := newFlagSet("graph")
+	formatName := flags.String("format", "cytoscape", "output format: "+strings.Join(render.GraphFormats(), ", "))
+	radius := flags.Int("radius", 1, "with an element, number of recipes away an exported element may be")
+	out := flags.String("out", "", "file written to, stdout when empty")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	format, ok := render.LookupGraph(*formatName)
+	if !ok {
+		return fmt.Errorf("format must be one of %s", strings.Join(render.GraphFormats(), ", "))
+	}
+	if *radius < 0 {
+		return fmt.Errorf("radius must not be negative")
+	}
+
+	graph, err := loadGraph(*recipes)
+	if err != nil {
+		return err
+	}
+	subgraph := render.WholeGraph(graph)
+	if len(positional) > 0 {
+		center, err := search.GetElementByName(graph, strings.Join(positional, " "))
+		if err != nil {
+			return err
+		}
+		subgraph = render.Neighborhood(graph, center, *radius)
+	}
+
+	if *out == "" {
+		return format.Write(os.Stdout, subgraph)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	err = format.Write(file, subgraph)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		fmt.Fprintf(os.Stderr, "%d elements and %d recipes written to %s\n", len(subgraph.Elements), len(subgraph.Recipes), *out)
+	}
+	return err
+}
+
+func runAnalyze(args []string) error {
+	flags, recipes := newFlagSet("analyze")
+	top := flags.Int("top", 10, "length of the degree rankings")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if positional, err := parseArgs(flags, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+
+	graph, err := loadGraph(*recipes)
+	if err != nil {
+		return err
+	}
+	report := analytics.Analyze(graph, *top)
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	fmt.Printf("%d elements, %d recipes\n", report.Elements, report.Recipes)
+	fmt.Println("\nUnreachable from the base elements:")
+	for _, set := range report.Unreachable {
+		fmt.Printf("  %-10s %d %s\n", set.Rules.Name, len(set.Elements), strings.Join(set.Elements, ", "))
+	}
+	fmt.Printf("\nTerminal elements: %d\n  %s\n", len(report.Terminal), strings.Join(report.Terminal, ", "))
+	fmt.Println("\nMost recipes (in-degree):")
+	for _, rank := range report.InDegree {
+		fmt.Printf("  %-24s tier %-2d %d\n", rank.Element, rank.Tier, rank.Degree)
+	}
+	fmt.Println("\nUsed in the most recipes (out-degree):")
+	for _, rank := range report.OutDegree {
+		fmt.Printf("  %-24s tier %-2d %d\n", rank.Element, rank.Tier, rank.Degree)
+	}
+	fmt.Printf("\nDiscarded by the search: %d by the tier rule, %d by the no recipe rule\n",
+		report.Discarded[algorithm.PruneTier], report.Discarded[algorithm.PruneNoRecipe])
+	for _, recipe := range report.TierDiscarded {
+		fmt.Printf("  %s (%d) = %s (%d) + %s (%d)\n", recipe.Result, recipe.ResultTier,
+			recipe.Ingredients[0], recipe.IngredientTiers[0], recipe.Ingredients[1], recipe.IngredientTiers[1])
+	}
+	return nil
+}
+
+// Size of the recipes file
+type datasetStats struct {
+	Version          string      `json:"version"`
+	Elements         int         `json:"elements"`
+	BaseElements     int         `json:"baseElements"`
+	Recipes          int         `json:"recipes"`
+	MaxTier          int         `json:"maxTier"`
+	ElementsPerTier  map[int]int `json:"elementsPerTier"`
+	WithoutRecipe    []string    `json:"withoutRecipe"` // Elements other than the base ones that cannot be crafted
+	MostRecipes      string      `json:"mostRecipes"`
+	MostRecipesCount int         `json:"mostRecipesCount"`
+	MostUsed         string      `json:"mostUsed"` // Ingredient of the most elements
+	MostUsedCount    int         `json:"mostUsedCount"`
+}
+
+func runStats(args []string) error {
+	flags, recipes := newFlagSet("stats")
+	asJSON := flags.Bool("json", false, "print the statistics as JSON")
+	if positional, err := parseArgs(flags, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+
+	entry, err := loadRecipes(*recipes)
+	if err != nil {
+		return err
+	}
+	var graph search.RecipeGraph
+	if err := search.ConstructRecipeGraph(entry, &graph); err != nil {
+		return err
+	}
+
+	stats := datasetStats{
+		Version:         scraping.DatasetVersion(entry),
+		Elements:        len(graph.Elements) - 1,
+		ElementsPerTier: make(map[int]int),
+		WithoutRecipe:   make([]string, 0),
+	}
+	for _, element := range graph.Elements[1:] {
+		stats.ElementsPerTier[element.Tier]++
+		stats.MaxTier = max(stats.MaxTier, element.Tier)
+		if len(element.Children) > stats.MostUsedCount {
+			stats.MostUsed, stats.MostUsedCount = element.Name, len(element.Children)
+		}
+
+		if slices.Contains(graph.BaseElements, element) {
+			stats.BaseElements++
+			continue
+		}
+		stats.Recipes += len(element.Recipes)
+		if len(element.Recipes) == 0 {
+			stats.WithoutRecipe = append(stats.WithoutRecipe, element.Name)
+		}
+		if len(element.Recipes) > stats.MostRecipesCount {
+			stats.MostRecipes, stats.MostRecipesCount = element.Name, len(element.Recipes)
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+	fmt.Printf("Version:        %s\n", stats.Version)
+	fmt.Printf("Elements:       %d (%d base)\n", stats.Elements, stats.BaseElements)
+	fmt.Printf("Recipes:        %d\n", stats.Recipes)
+	fmt.Printf("Tiers:          0-%d\n", stats.MaxTier)
+	for tier := 0; tier <= stats.MaxTier; tier++ {
+		fmt.Printf("  tier %-2d       %d\n", tier, stats.ElementsPerTier[tier])
+	}
+	fmt.Printf("Most recipes:   %s (%d)\n", stats.MostRecipes, stats.MostRecipesCount)
+	fmt.Printf("Most used:      %s (%d elements)\n", stats.MostUsed, stats.MostUsedCount)
+	fmt.Printf("Without recipe: %d\n", len(stats.WithoutRecipe))
+	for _, name := range stats.WithoutRecipe {
+		fmt.Printf("  %s\n", name)
+	}
+	return nil
+}
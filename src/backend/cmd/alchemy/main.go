@@ -0,0 +1,136 @@
+// Command alchemy searches the Little Alchemy 2 recipes without the web
+// server. It reads the same recipes file as the backend
+//
+//	go run ./cmd/alchemy search Brick -algo dfs -max 3
+//	go run ./cmd/alchemy search "Acid rain" -format mermaid > tree.mmd
+//	go run ./cmd/alchemy search Brick -format dot | dot -Tsvg > brick.svg
+package main
+
+import (
+	"backend/scraping"
+	"backend/search"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"scrape", "scrape [-icons] [-wiki-url url]", "scrape the wiki into the recipes file", runScrape},
+	{"validate", "validate", "check the recipes file for broken recipes", runValidate},
+	{"search", "search <element> [-algo bfs] [-max 1] [-max-depth 0] [-format text|json|mermaid|dot|ascii]", "find recipe trees of an element", runSearch},
+	{"stats", "stats [-json]", "summarize the recipes file", runStats},
+	{"graph", "graph [element] [-radius 1] [-format cytoscape|gexf|dot] [-out file]", "export the recipe graph, or the part around an element", runGraph},
+	{"analyze", "analyze [-top 10] [-json]", "report unreachable and terminal elements, degree rankings and discarded recipes", runAnalyze},
+	{"repl", "repl [-algo bfs]", "explore the recipe graph interactively", runREPL},
+	{"export", "export <element> [-algo bfs] [-max 5] [-format json|text|mermaid|dot|ascii] [-out dir]", "write every recipe tree of an element to its own file", runExport},
+}
+
+// Wrong arguments, exit code 2
+var errUsage = errors.New("usage")
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(os.Args[2:])
+		switch {
+		case err == nil:
+		case errors.Is(err, flag.ErrHelp):
+		case errors.Is(err, errUsage):
+			fmt.Fprintf(os.Stderr, "usage: alchemy %s\n", cmd.usage)
+			os.Exit(2)
+		default:
+			fmt.Fprintln(os.Stderr, "alchemy:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "alchemy: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: alchemy <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nEvery command takes -recipes <file>, default $ALCHEMY_RECIPES or scraping/recipes.json")
+}
+
+// Flag set of a command with the flags every command shares
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet("alchemy "+name, flag.ContinueOnError)
+	recipesPath := os.Getenv("ALCHEMY_RECIPES")
+	if recipesPath == "" {
+		recipesPath = "scraping/recipes.json"
+	}
+	recipes := flags.String("recipes", recipesPath, "JSON file the scraped recipes are written to and read from")
+	return flags, recipes
+}
+
+// Parses flags placed before and after the positional arguments, so that
+// "search Brick -algo dfs" works like "search -algo dfs Brick"
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// Element names may contain spaces, the positional words are joined back
+func elementName(positional []string) (string, error) {
+	if len(positional) == 0 {
+		return "", errUsage
+	}
+	return strings.Join(positional, " "), nil
+}
+
+func loadRecipes(path string) (scraping.RecipeEntry, error) {
+	scraping.SetResultPath(path)
+	recipes, err := scraping.GetScrapedRecipesJSON()
+	if err != nil {
+		return scraping.RecipeEntry{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return recipes, nil
+}
+
+func loadGraph(path string) (*search.RecipeGraph, error) {
+	recipes, err := loadRecipes(path)
+	if err != nil {
+		return nil, err
+	}
+	var graph search.RecipeGraph
+	if err := search.ConstructRecipeGraph(recipes, &graph); err != nil {
+		return nil, err
+	}
+	return &graph, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/render"
+	"encoding/json"
+	"io"
+)
+
+type outputFormat struct {
+	extension string
+	write     func(w io.Writer, result *algorithm.SearchResult) error
+}
+
+var formats = map[string]outputFormat{
+	"json": {"json", writeJSON},
+}
+
+// The renderers only write the trees
+func init() {
+	for _, name := range render.Formats() {
+		format, _ := render.Lookup(name)
+		formats[name] = outputFormat{format.Extension, func(w io.Writer, result *algorithm.SearchResult) error {
+			return format.Write(w, result.Trees)
+		}}
+	}
+}
+
+func formatNames() []string {
+	return append([]string{"json"}, render.Formats()...)
+}
+
+// Same fields as the data of /api/recipes
+type searchOutput struct {
+	Algo         string                  `json:"algo"`
+	Element      string                  `json:"element"`
+	Paths        []*algorithm.RecipeTree `json:"paths"`
+	VisitedNodes int                     `json:"visitedNodes"`
+	Duplicates   int                     `json:"duplicates"`
+	Stats        algorithm.SearchStats   `json:"stats"`
+}
+
+func writeJSON(w io.Writer, result *algorithm.SearchResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(searchOutput{
+		Algo:         result.Algorithm,
+		Element:      result.Trees[0].Target,
+		Paths:        result.Trees,
+		VisitedNodes: result.VisitedNodes,
+		Duplicates:   result.Duplicates,
+		Stats:        result.Stats,
+	})
+}
@@ -0,0 +1,586 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/analytics"
+	"backend/render"
+	"backend/search"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Interactive session over one recipe graph. The inventory starts with the
+// base elements and is only kept in memory
+type repl struct {
+	graph   *search.RecipeGraph
+	names   []string                       // Sorted element names, for completion
+	byName  map[string]*search.ElementNode // Lower case name to element
+	owned   map[int]bool                   // Inventory, by element ID
+	algo    string
+	timeout time.Duration
+	out     io.Writer
+}
+
+type replCommand struct {
+	name    string
+	usage   string
+	summary string
+	run     func(r *repl, arg string) error
+}
+
+// Set in init, the help command lists them
+var replCommands []replCommand
+
+func init() {
+	replCommands = []replCommand{
+		{"recipes", "recipes <element>", "list the recipes of an element", (*repl).recipes},
+		{"uses", "uses <element>", "list what an element is an ingredient of", (*repl).uses},
+		{"path", "path <element>", "show a recipe tree, owned elements are not crafted again", (*repl).path},
+		{"tier", "tier <number>", "list the elements of a tier", (*repl).tier},
+		{"diff", "diff <element> <element>", "compare the recipe trees of two elements", (*repl).diff},
+		{"dominators", "dominators <element>", "list the elements every recipe tree of an element goes through", (*repl).dominators},
+		{"info", "info <element>", "show the tier, recipe count and use count of an element", (*repl).info},
+		{"owned", "owned [add|remove <element>, ...|clear]", "show or change the inventory", (*repl).ownedCommand},
+		{"craftable", "craftable", "list what the inventory can craft right now", (*repl).craftable},
+		{"algo", "algo [name]", "show or change the algorithm used by path and diff", (*repl).setAlgo},
+		{"help", "help", "show this list", (*repl).help},
+		{"exit", "exit", "leave the session, Ctrl-D also works", nil},
+	}
+}
+
+func runREPL(args []string) error {
+	flags, recipes := newFlagSet("repl")
+	algo := flags.String("algo", "bfs", "search algorithm used by path and diff: "+strings.Join(algorithm.Algorithms(), ", "))
+	timeout := flags.Duration("timeout", 30*time.Second, "give up a search after this long")
+	if positional, err := parseArgs(flags, args); err != nil {
+		return err
+	} else if len(positional) > 0 {
+		return errUsage
+	}
+
+	graph, err := loadGraph(*recipes)
+	if err != nil {
+		return err
+	}
+	r := newREPL(graph, os.Stdout)
+	r.timeout = *timeout
+	if err := r.setAlgo(*algo); err != nil {
+		return err
+	}
+
+	// Piped input is read line by line, without prompt nor completion
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if !r.execute(scanner.Text()) {
+				break
+			}
+		}
+		return scanner.Err()
+	}
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "alchemy> ")
+	terminal.AutoCompleteCallback = r.complete(terminal)
+	if width, height, err := term.GetSize(int(os.Stdin.Fd())); err == nil && width > 0 {
+		terminal.SetSize(width, height)
+	}
+	r.out = terminal
+
+	fmt.Fprintf(r.out, "%d elements loaded. Type help for the commands, Tab completes element names\n", len(r.names))
+	for {
+		line, err := terminal.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil && !errors.Is(err, term.ErrPasteIndicator) {
+			return err
+		}
+		if !r.execute(line) {
+			return nil
+		}
+	}
+}
+
+func newREPL(graph *search.RecipeGraph, out io.Writer) *repl {
+	r := &repl{
+		graph:   graph,
+		names:   make([]string, 0, len(graph.Elements)),
+		byName:  make(map[string]*search.ElementNode, len(graph.Elements)),
+		owned:   make(map[int]bool),
+		algo:    "bfs",
+		timeout: 30 * time.Second,
+		out:     out,
+	}
+	for _, element := range graph.Elements[1:] {
+		r.names = append(r.names, element.Name)
+		r.byName[strings.ToLower(element.Name)] = element
+	}
+	slices.Sort(r.names)
+	r.resetInventory()
+	return r
+}
+
+// Runs one line, false once the session should end
+func (r *repl) execute(line string) bool {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	if name == "" {
+		return true
+	}
+	if name == "exit" || name == "quit" {
+		return false
+	}
+
+	for _, cmd := range replCommands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(r, arg); errors.Is(err, errUsage) {
+			fmt.Fprintf(r.out, "usage: %s\n", cmd.usage)
+		} else if err != nil {
+			fmt.Fprintln(r.out, "error:", err)
+		}
+		return true
+	}
+	fmt.Fprintf(r.out, "unknown command %q, type help for the commands\n", name)
+	return true
+}
+
+// Element names are matched without case
+func (r *repl) element(name string) (*search.ElementNode, error) {
+	if name == "" {
+		return nil, errUsage
+	}
+	element, ok := r.byName[strings.ToLower(name)]
+	if !ok {
+		return nil, &search.ElementNotFoundError{Name: name}
+	}
+	return element, nil
+}
+
+// Splits "Acid rain Life" into two element names, trying every split point
+// since names contain spaces. A comma separates them explicitly
+func (r *repl) elementPair(arg string) (*search.ElementNode, *search.ElementNode, error) {
+	if first, second, found := strings.Cut(arg, ","); found {
+		a, err := r.element(strings.TrimSpace(first))
+		if err != nil {
+			return nil, nil, err
+		}
+		b, err := r.element(strings.TrimSpace(second))
+		return a, b, err
+	}
+
+	words := strings.Fields(arg)
+	if len(words) < 2 {
+		return nil, nil, errUsage
+	}
+	for i := 1; i < len(words); i++ {
+		a, errA := r.element(strings.Join(words[:i], " "))
+		b, errB := r.element(strings.Join(words[i:], " "))
+		if errA == nil && errB == nil {
+			return a, b, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("cannot split %q into two elements, separate them with a comma", arg)
+}
+
+func (r *repl) mark(element *search.ElementNode) string {
+	if r.owned[element.ID] {
+		return element.Name + " *"
+	}
+	return element.Name
+}
+
+func (r *repl) recipes(arg string) error {
+	element, err := r.element(arg)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(r.graph.BaseElements, element) {
+		fmt.Fprintf(r.out, "%s is a base element\n", element.Name)
+		return nil
+	}
+	if len(element.Recipes) == 0 {
+		fmt.Fprintf(r.out, "%s has no recipe\n", element.Name)
+		return nil
+	}
+	for _, recipe := range element.Recipes {
+		fmt.Fprintf(r.out, "%s = %s + %s\n", element.Name, r.mark(recipe[0]), r.mark(recipe[1]))
+	}
+	fmt.Fprintln(r.out, "(* owned)")
+	return nil
+}
+
+func (r *repl) uses(arg string) error {
+	element, err := r.element(arg)
+	if err != nil {
+		return err
+	}
+
+	lines := make([]string, 0)
+	for _, child := range element.Children {
+		for _, recipe := range child.Recipes {
+			switch element {
+			case recipe[0]:
+				lines = append(lines, fmt.Sprintf("%s + %s = %s", element.Name, r.mark(recipe[1]), child.Name))
+			case recipe[1]:
+				lines = append(lines, fmt.Sprintf("%s + %s = %s", element.Name, r.mark(recipe[0]), child.Name))
+			}
+		}
+	}
+	if len(lines) == 0 {
+		fmt.Fprintf(r.out, "%s is not an ingredient of anything\n", element.Name)
+		return nil
+	}
+	slices.Sort(lines)
+	lines = slices.Compact(lines)
+	for _, line := range lines {
+		fmt.Fprintln(r.out, line)
+	}
+	fmt.Fprintf(r.out, "%d recipes use %s (* owned)\n", len(lines), element.Name)
+	return nil
+}
+
+func (r *repl) searchTree(element *search.ElementNode) (*algorithm.RecipeTree, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	result, err := algorithm.Search(ctx, r.algo, element, r.graph, algorithm.SearchOptions{MaxPaths: 1})
+	if err != nil {
+		return nil, err
+	}
+	return result.Trees[0], nil
+}
+
+// Copy of node where owned elements below the root are leaves, they do not
+// need to be crafted again
+func (r *repl) withoutOwned(node *algorithm.TreeNode, root bool) *algorithm.TreeNode {
+	copied := *node
+	if !root && r.owned[node.ID] {
+		copied.Ingredients = []*algorithm.TreeNode{}
+		return &copied
+	}
+	copied.Ingredients = make([]*algorithm.TreeNode, len(node.Ingredients))
+	for i, ingredient := range node.Ingredients {
+		copied.Ingredients[i] = r.withoutOwned(ingredient, false)
+	}
+	return &copied
+}
+
+func countCrafts(node *algorithm.TreeNode) int {
+	if len(node.Ingredients) == 0 {
+		return 0
+	}
+	crafts := 1
+	for _, ingredient := range node.Ingredients {
+		crafts += countCrafts(ingredient)
+	}
+	return crafts
+}
+
+func (r *repl) path(arg string) error {
+	element, err := r.element(arg)
+	if err != nil {
+		return err
+	}
+	tree, err := r.searchTree(element)
+	if err != nil {
+		return err
+	}
+
+	root := r.withoutOwned(tree.Root, true)
+	render.WriteTree(r.out, root, render.Unicode, func(node *algorithm.TreeNode) string {
+		if r.owned[node.ID] {
+			return node.Name + " *"
+		}
+		return render.TierLabel(node)
+	})
+	fmt.Fprintf(r.out, "%d crafts, %d left with the inventory (* owned)\n", tree.Crafts, countCrafts(root))
+	return nil
+}
+
+func (r *repl) tier(arg string) error {
+	tier, err := strconv.Atoi(arg)
+	if err != nil {
+		return errUsage
+	}
+	names := make([]string, 0)
+	for _, name := range r.names {
+		if r.byName[strings.ToLower(name)].Tier == tier {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(r.out, "No element of tier %d\n", tier)
+		return nil
+	}
+	fmt.Fprintf(r.out, "Tier %d, %d elements:\n%s\n", tier, len(names), strings.Join(names, ", "))
+	return nil
+}
+
+func treeElements(node *algorithm.TreeNode, names map[string]bool) {
+	names[node.Name] = true
+	for _, ingredient := range node.Ingredients {
+		treeElements(ingredient, names)
+	}
+}
+
+func (r *repl) diff(arg string) error {
+	a, b, err := r.elementPair(arg)
+	if err != nil {
+		return err
+	}
+	treeA, err := r.searchTree(a)
+	if err != nil {
+		return err
+	}
+	treeB, err := r.searchTree(b)
+	if err != nil {
+		return err
+	}
+
+	inA, inB := make(map[string]bool), make(map[string]bool)
+	treeElements(treeA.Root, inA)
+	treeElements(treeB.Root, inB)
+	onlyA, onlyB, both := make([]string, 0), make([]string, 0), make([]string, 0)
+	for name := range inA {
+		if inB[name] {
+			both = append(both, name)
+		} else {
+			onlyA = append(onlyA, name)
+		}
+	}
+	for name := range inB {
+		if !inA[name] {
+			onlyB = append(onlyB, name)
+		}
+	}
+	slices.Sort(onlyA)
+	slices.Sort(onlyB)
+	slices.Sort(both)
+
+	fmt.Fprintf(r.out, "%-12s %-20s %s\n", "", a.Name, b.Name)
+	fmt.Fprintf(r.out, "%-12s %-20d %d\n", "tier", a.Tier, b.Tier)
+	fmt.Fprintf(r.out, "%-12s %-20d %d\n", "crafts", treeA.Crafts, treeB.Crafts)
+	fmt.Fprintf(r.out, "%-12s %-20d %d\n", "depth", treeA.Depth, treeB.Depth)
+	fmt.Fprintf(r.out, "Only in %s: %s\n", a.Name, strings.Join(onlyA, ", "))
+	fmt.Fprintf(r.out, "Only in %s: %s\n", b.Name, strings.Join(onlyB, ", "))
+	fmt.Fprintf(r.out, "In both: %s\n", strings.Join(both, ", "))
+	return nil
+}
+
+func (r *repl) dominators(arg string) error {
+	target, err := r.element(arg)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(r.graph.BaseElements, target) {
+		return algorithm.ErrBaseElement
+	}
+	dominators := analytics.ComputeDominators(r.graph).Of(target)
+	if dominators == nil {
+		return fmt.Errorf("%w for %s", algorithm.ErrUnreachable, target.Name)
+	}
+
+	names := make([]string, 0, len(dominators))
+	for _, element := range dominators {
+		switch {
+		case element == target:
+		case element.Tier > 0:
+			names = append(names, fmt.Sprintf("%s (tier %d)", element.Name, element.Tier))
+		default:
+			names = append(names, element.Name)
+		}
+	}
+	fmt.Fprintf(r.out, "Every recipe tree of %s goes through: %s\n", target.Name, strings.Join(names, ", "))
+	return nil
+}
+
+func (r *repl) info(arg string) error {
+	element, err := r.element(arg)
+	if err != nil {
+		return err
+	}
+	owned := "no"
+	if r.owned[element.ID] {
+		owned = "yes"
+	}
+	fmt.Fprintf(r.out, "%s: tier %d, %d recipes, ingredient of %d elements, owned: %s\n",
+		element.Name, element.Tier, len(element.Recipes), len(element.Children), owned)
+	return nil
+}
+
+func (r *repl) resetInventory() {
+	clear(r.owned)
+	for _, base := range r.graph.BaseElements {
+		if base != nil {
+			r.owned[base.ID] = true
+		}
+	}
+}
+
+func (r *repl) ownedCommand(arg string) error {
+	action, rest, _ := strings.Cut(arg, " ")
+	switch action {
+	case "", "list":
+		names := make([]string, 0, len(r.owned))
+		for _, name := range r.names {
+			if r.owned[r.byName[strings.ToLower(name)].ID] {
+				names = append(names, name)
+			}
+		}
+		fmt.Fprintf(r.out, "%d owned: %s\n", len(names), strings.Join(names, ", "))
+	case "add", "remove":
+		if strings.TrimSpace(rest) == "" {
+			return errUsage
+		}
+		for _, name := range strings.Split(rest, ",") {
+			element, err := r.element(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			if action == "add" {
+				r.owned[element.ID] = true
+				fmt.Fprintf(r.out, "Added %s\n", element.Name)
+			} else {
+				delete(r.owned, element.ID)
+				fmt.Fprintf(r.out, "Removed %s\n", element.Name)
+			}
+		}
+	case "clear":
+		r.resetInventory()
+		fmt.Fprintln(r.out, "Inventory reset to the base elements")
+	default:
+		return errUsage
+	}
+	return nil
+}
+
+func (r *repl) craftable(arg string) error {
+	names := make([]string, 0)
+	for _, element := range r.graph.Elements[1:] {
+		if r.owned[element.ID] {
+			continue
+		}
+		for _, recipe := range element.Recipes {
+			if r.owned[recipe[0].ID] && r.owned[recipe[1].ID] {
+				names = append(names, element.Name)
+				break
+			}
+		}
+	}
+	slices.Sort(names)
+	fmt.Fprintf(r.out, "%d craftable: %s\n", len(names), strings.Join(names, ", "))
+	return nil
+}
+
+func (r *repl) setAlgo(arg string) error {
+	if arg == "" {
+		fmt.Fprintf(r.out, "Algorithm: %s\n", r.algo)
+		return nil
+	}
+	algo := strings.ToLower(arg)
+	if !algorithm.IsAlgorithm(algo) {
+		return fmt.Errorf("algorithm must be one of %s", strings.Join(algorithm.Algorithms(), ", "))
+	}
+	r.algo = algo
+	return nil
+}
+
+func (r *repl) help(string) error {
+	for _, cmd := range replCommands {
+		fmt.Fprintf(r.out, "  %-42s %s\n", cmd.usage, cmd.summary)
+	}
+	return nil
+}
+
+// Tab completes the command name, then element names. Several candidates
+// are completed to their common prefix and listed
+func (r *repl) complete(terminal *term.Terminal) func(line string, pos int, key rune) (string, int, bool) {
+	return func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		before, after := line[:pos], line[pos:]
+
+		var start int
+		var options []string
+		if name, _, found := strings.Cut(before, " "); !found {
+			for _, cmd := range replCommands {
+				options = append(options, cmd.name)
+			}
+		} else {
+			start = len(name) + 1
+			if name == "owned" {
+				action, _, _ := strings.Cut(before[start:], " ")
+				if action == "add" || action == "remove" {
+					start += len(action) + 1
+				}
+			}
+			// After a comma the next element starts
+			if comma := strings.LastIndex(before, ","); comma >= start {
+				start = comma + 1
+			}
+			for start < len(before) && before[start] == ' ' {
+				start++
+			}
+			options = r.names
+		}
+
+		prefix := strings.ToLower(before[start:])
+		matches := make([]string, 0)
+		for _, option := range options {
+			if strings.HasPrefix(strings.ToLower(option), prefix) {
+				matches = append(matches, option)
+			}
+		}
+		if len(matches) == 0 {
+			return "", 0, false
+		}
+
+		completed := matches[0]
+		if len(matches) > 1 {
+			completed = completed[:commonPrefixLength(matches)]
+			if len(completed) <= len(prefix) {
+				fmt.Fprintln(terminal, strings.Join(matches, "  "))
+				return "", 0, false
+			}
+		} else if start == 0 {
+			completed += " "
+		}
+		return before[:start] + completed + after, start + len(completed), true
+	}
+}
+
+// Length of the prefix every name shares, without case
+func commonPrefixLength(names []string) int {
+	length := len(names[0])
+	for _, name := range names[1:] {
+		length = min(length, len(name))
+		for i := 0; i < length; i++ {
+			if strings.ToLower(name[i:i+1]) != strings.ToLower(names[0][i:i+1]) {
+				length = i
+				break
+			}
+		}
+	}
+	return length
+}
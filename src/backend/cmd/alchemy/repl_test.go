@@ -0,0 +1,224 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/algorithm/algotest"
+	"backend/search"
+	"backend/search/graphtest"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/term"
+)
+
+// Mud and Steam are tier 1, Brick and Rain tier 2 and Acid rain, whose name
+// has a space, tier 3
+func replGraph(t *testing.T) *search.RecipeGraph {
+	return graphtest.New().
+		Add("Mud", "Water", "Earth").
+		Add("Steam", "Water", "Fire").
+		Add("Brick", "Mud", "Fire").
+		Add("Rain", "Steam", "Air").
+		Add("Acid rain", "Rain", "Fire").
+		Graph(t)
+}
+
+func TestElementPair(t *testing.T) {
+	r := newREPL(replGraph(t), io.Discard)
+	tests := []struct {
+		arg     string
+		a, b    string
+		wantErr bool
+	}{
+		{"Mud Brick", "Mud", "Brick", false},
+		{"acid rain mud", "Acid rain", "Mud", false},
+		{"Acid rain Rain", "Acid rain", "Rain", false},
+		{"Rain, Acid rain", "Rain", "Acid rain", false},
+		{"Mud", "", "", true},
+		{"", "", "", true},
+		{"Mud Plasma", "", "", true},
+		{"Mud, Plasma", "", "", true},
+	}
+	for _, test := range tests {
+		a, b, err := r.elementPair(test.arg)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%q: %s and %s, want an error", test.arg, a.Name, b.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.arg, err)
+			continue
+		}
+		if a.Name != test.a || b.Name != test.b {
+			t.Errorf("%q: %s and %s, want %s and %s", test.arg, a.Name, b.Name, test.a, test.b)
+		}
+	}
+
+	// A single word is a usage error, an unknown element is not
+	if _, _, err := r.elementPair("Mud"); !errors.Is(err, errUsage) {
+		t.Errorf("one element: %v, want the usage", err)
+	}
+	var notFound *search.ElementNotFoundError
+	if _, _, err := r.elementPair("Mud, Plasma"); !errors.As(err, &notFound) {
+		t.Errorf("unknown element: %v, want ElementNotFoundError", err)
+	}
+}
+
+func TestCommonPrefixLength(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  int
+	}{
+		{[]string{"Air"}, 3},
+		{[]string{"Steam", "Stone"}, 2},
+		{[]string{"Air", "Airplane"}, 3},
+		{[]string{"acid", "ACID rain"}, 4},
+		{[]string{"Mud", "Steam", "Stone"}, 0},
+	}
+	for _, test := range tests {
+		if got := commonPrefixLength(test.names); got != test.want {
+			t.Errorf("%q: %d, want %d", test.names, got, test.want)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		line    string
+		pos     int // -1 for the end of line
+		want    string
+		wantPos int
+		listed  string // Candidates printed when there is nothing to complete
+	}{
+		{"rec", -1, "recipes ", 8, ""},
+		{"d", -1, "", 0, "diff  dominators"},
+		{"recipes ac", -1, "recipes Acid rain", 17, ""},
+		{"recipes M", -1, "recipes Mud", 11, ""},
+		{"recipes s", -1, "recipes Steam", 13, ""},
+		{"recipes m Fire", 9, "recipes Mud Fire", 11, ""},
+		{"owned add Mud, st", -1, "owned add Mud, Steam", 20, ""},
+		{"diff Mud,bri", -1, "diff Mud,Brick", 14, ""},
+		{"recipes a", -1, "", 0, "Acid rain  Air"},
+		{"recipes xyz", -1, "", 0, ""},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		terminal := term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{strings.NewReader(""), &out}, "")
+		complete := newREPL(replGraph(t), io.Discard).complete(terminal)
+
+		pos := test.pos
+		if pos < 0 {
+			pos = len(test.line)
+		}
+		got, gotPos, ok := complete(test.line, pos, '\t')
+		if ok != (test.want != "") || got != test.want || gotPos != test.wantPos {
+			t.Errorf("%q at %d: %q at %d (%t), want %q at %d", test.line, pos, got, gotPos, ok, test.want, test.wantPos)
+		}
+		if !strings.Contains(out.String(), test.listed) || (test.listed == "" && out.Len() > 0) {
+			t.Errorf("%q: listed %q, want %q", test.line, out.String(), test.listed)
+		}
+	}
+
+	complete := newREPL(replGraph(t), io.Discard).complete(nil)
+	if _, _, ok := complete("rec", 3, 'a'); ok {
+		t.Error("completed on another key than tab")
+	}
+}
+
+func TestWithoutOwned(t *testing.T) {
+	graph := replGraph(t)
+	r := newREPL(graph, io.Discard)
+	acidRain, _ := search.GetElementByName(graph, "Acid rain")
+	result, err := algotest.Search(t, "bfs", graph, acidRain, algorithm.SearchOptions{MaxPaths: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := result.Trees[0]
+
+	tests := []struct {
+		owned  []string
+		want   string
+		crafts int
+	}{
+		{nil, "Acid rain(Rain(Steam(Water, Fire), Air), Fire)", 3},
+		{[]string{"Steam"}, "Acid rain(Rain(Steam, Air), Fire)", 2},
+		{[]string{"Rain", "Steam"}, "Acid rain(Rain, Fire)", 1},
+		// The root is crafted even when owned
+		{[]string{"Acid rain"}, "Acid rain(Rain(Steam(Water, Fire), Air), Fire)", 3},
+	}
+	for _, test := range tests {
+		r.resetInventory()
+		for _, name := range test.owned {
+			element, _ := r.element(name)
+			r.owned[element.ID] = true
+		}
+		root := r.withoutOwned(tree.Root, true)
+		if got := algotest.Notation(&algorithm.RecipeTree{Root: root}); got != test.want {
+			t.Errorf("owning %v: %s, want %s", test.owned, got, test.want)
+		}
+		if crafts := countCrafts(root); crafts != test.crafts {
+			t.Errorf("owning %v: %d crafts, want %d", test.owned, crafts, test.crafts)
+		}
+	}
+	if got := algotest.Notation(tree); got != tests[0].want {
+		t.Errorf("the search tree was changed to %s", got)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  []string // Expected in the output of the last line
+		stop  bool     // The last line ends the session
+	}{
+		{[]string{""}, nil, false},
+		{[]string{"exit"}, nil, true},
+		{[]string{"quit"}, nil, true},
+		{[]string{"bogus"}, []string{`unknown command "bogus"`}, false},
+		{[]string{"recipes"}, []string{"usage: recipes <element>"}, false},
+		{[]string{"recipes Plasma"}, []string{"error: "}, false},
+		{[]string{"recipes mud"}, []string{"Mud = Water * + Earth *"}, false},
+		{[]string{"recipes Air"}, []string{"Air is a base element"}, false},
+		{[]string{"uses Steam"}, []string{"Steam + Air * = Rain", "1 recipes use Steam"}, false},
+		{[]string{"tier 1"}, []string{"Tier 1, 2 elements:\nMud, Steam"}, false},
+		{[]string{"tier x"}, []string{"usage: tier <number>"}, false},
+		{[]string{"info Rain"}, []string{"Rain: tier 2, 1 recipes, ingredient of 1 elements, owned: no"}, false},
+		{[]string{"craftable"}, []string{"2 craftable: Mud, Steam"}, false},
+		{[]string{"owned add Mud, Steam", "craftable"}, []string{"2 craftable: Brick, Rain"}, false},
+		{[]string{"owned add Mud", "owned clear", "owned"}, []string{"4 owned: Air, Earth, Fire, Water"}, false},
+		{[]string{"owned remove"}, []string{"usage: owned"}, false},
+		{[]string{"path Brick"}, []string{"Brick (tier 2)", "2 crafts, 2 left"}, false},
+		{[]string{"owned add Mud", "path Brick"}, []string{"Mud *", "2 crafts, 1 left"}, false},
+		{[]string{"diff Mud Steam"}, []string{"Only in Mud: Earth, Mud", "Only in Steam: Fire, Steam", "In both: Water"}, false},
+		{[]string{"algo dfs", "algo"}, []string{"Algorithm: dfs"}, false},
+		{[]string{"algo nope"}, []string{"error: algorithm must be one of"}, false},
+		{[]string{"dominators Air"}, []string{"error: "}, false},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		r := newREPL(replGraph(t), &out)
+		var running bool
+		for _, line := range test.lines {
+			out.Reset()
+			running = r.execute(line)
+		}
+		if running == test.stop {
+			t.Errorf("%q: session running is %t", test.lines, running)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%q: no %q in\n%s", test.lines, want, out.String())
+			}
+		}
+		if test.want == nil && out.Len() > 0 {
+			t.Errorf("%q: printed %q", test.lines, out.String())
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/metrics"
+	"backend/search"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/compare?element=Brick&max=3
+//
+// Runs every algorithm one after the other with the same options, so their
+// durations are not skewed by each other. The searches neither read nor
+// fill the shared caches, so every algorithm does its whole work and the
+// cached results of other requests are kept
+func handleCompare(datasets *search.GraphStore, timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request CompareRequest
+		if err := c.ShouldBindQuery(&request); err != nil {
+			c.Error(invalidParameter("Invalid query: %v", err))
+			return
+		}
+		graph := datasets.Current().Graph
+		target, err := search.GetElementByName(graph, request.Element)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		options := algorithm.SearchOptions{
+			MaxPaths: request.Max,
+			MaxDepth: request.MaxDepth,
+			Dedup:    request.Max > 1,
+			NoCache:  true,
+		}
+		data := CompareData{
+			Element:  target.Name,
+			Max:      request.Max,
+			MaxDepth: request.MaxDepth,
+			Results:  make([]AlgorithmComparison, 0, len(algorithm.Algorithms())),
+		}
+		for _, algo := range algorithm.Algorithms() {
+			comparison, err := compareAlgorithm(c.Request.Context(), algo, target, graph, options, timeout)
+			if errors.Is(err, context.Canceled) {
+				c.Error(err)
+				return
+			}
+			data.Results = append(data.Results, comparison)
+		}
+		c.JSON(http.StatusOK, CompareResponse{
+			Error: false,
+			Data:  data,
+		})
+	}
+}
+
+// A failed search is part of the comparison, not an error of the request
+func compareAlgorithm(ctx context.Context, algo string, target *search.ElementNode, graph *search.RecipeGraph, options algorithm.SearchOptions, timeout time.Duration) (AlgorithmComparison, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	comparison := AlgorithmComparison{Algo: algo}
+	result, err := algorithm.Search(ctx, algo, target, graph, options)
+	if result != nil {
+		metrics.ObserveSearch(algo, result.Stats)
+		comparison.DurationMs = result.Stats.DurationMs
+		comparison.VisitedNodes = result.VisitedNodes
+		comparison.Trees = len(result.Trees)
+		for i, tree := range result.Trees {
+			if i == 0 || tree.Crafts < comparison.Crafts {
+				comparison.Crafts, comparison.Depth = tree.Crafts, tree.Depth
+			}
+		}
+	}
+	if err != nil {
+		apiErr := toAPIError(err)
+		comparison.ErrorType, comparison.Message = apiErr.Type, apiErr.Message
+	}
+	return comparison, err
+}
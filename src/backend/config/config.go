@@ -0,0 +1,229 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings of the backend. Every field can be set, from lowest to highest
+// priority, by its default, the YAML config file, an ALCHEMY_* environment
+// variable and a command line flag. See config.example.yaml
+type Config struct {
+	Server  ServerConfig  `yaml:"server"`
+	Dataset DatasetConfig `yaml:"dataset"`
+	Search  SearchConfig  `yaml:"search"`
+}
+
+type ServerConfig struct {
+	Port            int           `yaml:"port"`
+	CORSOrigins     []string      `yaml:"corsOrigins"`
+	TrustedProxies  []string      `yaml:"trustedProxies"`
+	ReadTimeout     time.Duration `yaml:"readTimeout"`
+	WriteTimeout    time.Duration `yaml:"writeTimeout"`    // 0 disables it, streaming endpoints stay open as long as the search runs
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"` // How long running requests may take to finish on SIGINT or SIGTERM
+	AdminToken      string        `yaml:"adminToken"`      // Bearer token of /admin endpoints. Empty only allows localhost
+}
+
+type DatasetConfig struct {
+	RecipesPath     string `yaml:"recipesPath"`
+	WikiURL         string `yaml:"wikiURL"`
+	ScrapeAtStartup bool   `yaml:"scrapeAtStartup"` // Otherwise the recipes are read from RecipesPath
+	ScrapeIcons     bool   `yaml:"scrapeIcons"`
+}
+
+type SearchConfig struct {
+	BFSThreads       int           `yaml:"bfsThreads"`
+	BFSMaxIterations int           `yaml:"bfsMaxIterations"`
+	MaxPaths         int           `yaml:"maxPaths"` // Largest max accepted by /api/recipes
+	CacheSize        int           `yaml:"cacheSize"`
+	ElementCacheSize int           `yaml:"elementCacheSize"`
+	Timeout          time.Duration `yaml:"timeout"` // Longest a search may run before the request fails
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            8080,
+			CORSOrigins:     []string{"http://localhost:3000"},
+			TrustedProxies:  []string{"127.0.0.1"},
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    0,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Dataset: DatasetConfig{
+			RecipesPath:     "scraping/recipes.json",
+			WikiURL:         "https://little-alchemy.fandom.com/wiki/Elements_(Little_Alchemy_2)",
+			ScrapeAtStartup: true,
+			ScrapeIcons:     false,
+		},
+		Search: SearchConfig{
+			BFSThreads:       4,
+			BFSMaxIterations: 1000,
+			MaxPaths:         100,
+			CacheSize:        1024,
+			ElementCacheSize: 4096,
+			Timeout:          30 * time.Second,
+		},
+	}
+}
+
+// Builds the configuration from args (without the program name), the
+// environment and the config file given by -config or ALCHEMY_CONFIG
+func Load(args []string) (Config, error) {
+	// First pass only finds the config file and rejects unknown flags
+	var path string
+	probe := Default()
+	if err := newFlagSet(&probe, &path).Parse(args); err != nil {
+		return Config{}, err
+	}
+	if path == "" {
+		path = os.Getenv("ALCHEMY_CONFIG")
+	}
+
+	cfg := Default()
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	flags := newFlagSet(&cfg, &path)
+	var envErr error
+	flags.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || f.Name == "config" {
+			return
+		}
+		if err := flags.Set(f.Name, value); err != nil {
+			envErr = errors.Join(envErr, fmt.Errorf("%s: %w", envName(f.Name), err))
+		}
+	})
+	if envErr != nil {
+		return Config{}, envErr
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	defer file.Close()
+
+	// Misspelled keys are errors instead of silently keeping the default
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func newFlagSet(c *Config, path *string) *flag.FlagSet {
+	flags := flag.NewFlagSet("backend", flag.ContinueOnError)
+	flags.StringVar(path, "config", *path, "YAML config file")
+
+	flags.IntVar(&c.Server.Port, "port", c.Server.Port, "port the HTTP server listens on")
+	flags.Var((*stringList)(&c.Server.CORSOrigins), "cors-origin", "comma separated origins allowed by CORS and the WebSocket session")
+	flags.Var((*stringList)(&c.Server.TrustedProxies), "trusted-proxy", "comma separated proxies trusted for the client IP")
+	flags.DurationVar(&c.Server.ReadTimeout, "read-timeout", c.Server.ReadTimeout, "maximum time to read a request, 0 for none")
+	flags.DurationVar(&c.Server.WriteTimeout, "write-timeout", c.Server.WriteTimeout, "maximum time to write a response, 0 for none")
+	flags.DurationVar(&c.Server.ShutdownTimeout, "shutdown-timeout", c.Server.ShutdownTimeout, "maximum time running requests get to finish when stopping")
+	flags.StringVar(&c.Server.AdminToken, "admin-token", c.Server.AdminToken, "bearer token of the admin endpoints, empty to only allow localhost")
+
+	flags.StringVar(&c.Dataset.RecipesPath, "recipes", c.Dataset.RecipesPath, "JSON file the scraped recipes are written to and read from")
+	flags.StringVar(&c.Dataset.WikiURL, "wiki-url", c.Dataset.WikiURL, "wiki page listing the elements and their recipes")
+	flags.BoolVar(&c.Dataset.ScrapeAtStartup, "scrape", c.Dataset.ScrapeAtStartup, "scrape the wiki at startup instead of only reading the recipes file")
+	flags.BoolVar(&c.Dataset.ScrapeIcons, "scrape-icons", c.Dataset.ScrapeIcons, "also download the element icons when scraping")
+
+	flags.IntVar(&c.Search.BFSThreads, "bfs-threads", c.Search.BFSThreads, "goroutines expanding each BFS level")
+	flags.IntVar(&c.Search.BFSMaxIterations, "bfs-max-iterations", c.Search.BFSMaxIterations, "elements BFS expands before giving up")
+	flags.IntVar(&c.Search.MaxPaths, "max-paths", c.Search.MaxPaths, "largest number of recipes one request may ask for")
+	flags.IntVar(&c.Search.CacheSize, "cache-size", c.Search.CacheSize, "recipe trees kept by the subtree cache, 0 to disable")
+	flags.IntVar(&c.Search.ElementCacheSize, "element-cache-size", c.Search.ElementCacheSize, "subtrees of single elements kept for DFS and IDDFS, 0 to disable")
+	flags.DurationVar(&c.Search.Timeout, "search-timeout", c.Search.Timeout, "longest a search may run before the request fails")
+	return flags
+}
+
+// -bfs-max-iterations is read from ALCHEMY_BFS_MAX_ITERATIONS
+func envName(flagName string) string {
+	return "ALCHEMY_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Reports every invalid setting at once
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "port must be between 1 and 65535, got %d", c.Server.Port)
+	check(len(c.Server.CORSOrigins) > 0, "at least one CORS origin is required")
+	for _, origin := range c.Server.CORSOrigins {
+		check(isHTTPURL(origin), "CORS origin %q must be an http or https URL", origin)
+	}
+	check(c.Server.ReadTimeout >= 0, "read timeout must not be negative")
+	check(c.Server.WriteTimeout >= 0, "write timeout must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "shutdown timeout must be greater than 0")
+
+	check(c.Dataset.RecipesPath != "", "recipes path is required")
+	check(isHTTPURL(c.Dataset.WikiURL), "wiki URL %q must be an http or https URL", c.Dataset.WikiURL)
+
+	check(c.Search.BFSThreads > 0, "BFS threads must be greater than 0, got %d", c.Search.BFSThreads)
+	check(c.Search.BFSMaxIterations > 0, "BFS max iterations must be greater than 0, got %d", c.Search.BFSMaxIterations)
+	check(c.Search.MaxPaths > 0, "max paths must be greater than 0, got %d", c.Search.MaxPaths)
+	check(c.Search.CacheSize >= 0, "cache size must not be negative, got %d", c.Search.CacheSize)
+	check(c.Search.ElementCacheSize >= 0, "element cache size must not be negative, got %d", c.Search.ElementCacheSize)
+	check(c.Search.Timeout > 0, "search timeout must be greater than 0")
+
+	return errors.Join(errs...)
+}
+
+// Address the server listens on
+func (c ServerConfig) Addr() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Comma separated flag. Setting it replaces the default instead of appending
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*l = items
+	return nil
+}
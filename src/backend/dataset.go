@@ -0,0 +1,135 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/metrics"
+	"backend/scraping"
+	"backend/search"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Loads the recipe dataset into the store, at startup and on every reload.
+// Only one load runs at a time
+type datasetLoader struct {
+	mu          sync.Mutex
+	scrapeIcons bool
+	datasets    *search.GraphStore
+	health      *serverHealth
+}
+
+// Scrapes the wiki if asked and builds a new graph from the recipes file.
+// A failed scrape falls back to the recipes scraped before. The current
+// graph is only replaced once the new one is built
+func (l *datasetLoader) load(scrape bool) (*search.Snapshot, *search.Snapshot, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if scrape {
+		if err := scraping.ScrapeRecipes(l.scrapeIcons); err != nil {
+			fmt.Println("Scraping failed, using the existing recipes file:", err)
+		}
+	}
+
+	snapshot, previous, err := l.build()
+	metrics.ObserveDatasetLoad(err == nil)
+	l.health.setLoadError(err)
+	return snapshot, previous, err
+}
+
+func (l *datasetLoader) build() (*search.Snapshot, *search.Snapshot, error) {
+	recipes, err := scraping.GetScrapedRecipesJSON()
+	if err != nil {
+		return nil, nil, err
+	}
+	var graph search.RecipeGraph
+	if err := search.ConstructRecipeGraph(recipes, &graph); err != nil {
+		return nil, nil, err
+	}
+
+	version := scraping.DatasetVersion(recipes)
+	snapshot, previous := l.datasets.Swap(&graph, version)
+	algorithm.InvalidateCache()
+	metrics.SetGraph(&graph, version)
+	return snapshot, previous, nil
+}
+
+// Reloads the recipes file on every SIGHUP until ctx is done
+func (l *datasetLoader) reloadOnSIGHUP(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			if snapshot, _, err := l.load(false); err != nil {
+				fmt.Println("Reload failed, keeping the current dataset:", err)
+			} else {
+				fmt.Println("Reloaded dataset", snapshot.Version)
+			}
+		}
+	}
+}
+
+// POST /admin/reload?scrape=true
+func (l *datasetLoader) handleReload(c *gin.Context) {
+	scrape, err := strconv.ParseBool(c.Query("scrape"))
+	if err != nil {
+		c.Error(invalidParameter("Scrape parameter must be true or false"))
+		return
+	}
+
+	snapshot, previous, err := l.load(scrape)
+	if err != nil {
+		c.Error(&apiError{http.StatusInternalServerError, "reload_failed", fmt.Sprintf("Reload failed, keeping the current dataset: %v", err)})
+		return
+	}
+
+	data := ReloadData{
+		Version:  snapshot.Version,
+		Elements: snapshot.Elements,
+		LoadedAt: snapshot.LoadedAt,
+		Changed:  previous == nil || previous.Version != snapshot.Version,
+	}
+	if previous != nil {
+		data.PreviousVersion = previous.Version
+	}
+	c.JSON(http.StatusOK, ReloadResponse{
+		Error: false,
+		Data:  data,
+	})
+}
+
+// Admin endpoints need "Authorization: Bearer <token>". Without a configured
+// token they only accept requests from the machine itself
+func requireAdmin(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := false
+		if token == "" {
+			ip := c.RemoteIP()
+			allowed = ip == "127.0.0.1" || ip == "::1"
+		} else {
+			given, bearer := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			allowed = bearer && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+		}
+
+		if !allowed {
+			abortWithError(c, errForbidden)
+			return
+		}
+		c.Next()
+	}
+}
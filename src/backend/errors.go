@@ -0,0 +1,105 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/search"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error already in the shape sent to clients:
+//
+//	{"error": true, "type": "missing_parameter", "message": "Element parameter is required"}
+type apiError struct {
+	Status  int
+	Type    string
+	Message string
+}
+
+func (e *apiError) Error() string { return e.Message }
+
+func missingParameter(name string) *apiError {
+	return &apiError{http.StatusBadRequest, "missing_parameter", fmt.Sprintf("%s parameter is required", name)}
+}
+
+func invalidParameter(format string, args ...any) *apiError {
+	return &apiError{http.StatusBadRequest, "invalid_parameter", fmt.Sprintf(format, args...)}
+}
+
+func invalidBody(format string, args ...any) *apiError {
+	return &apiError{http.StatusBadRequest, "invalid_body", fmt.Sprintf(format, args...)}
+}
+
+func bodyTooLarge(limit int64) *apiError {
+	return &apiError{http.StatusRequestEntityTooLarge, "body_too_large", fmt.Sprintf("Body must not exceed %d bytes", limit)}
+}
+
+var (
+	errDatasetNotReady = &apiError{http.StatusServiceUnavailable, "dataset_not_ready", "The recipe dataset is not loaded yet, try again later"}
+	errForbidden       = &apiError{http.StatusForbidden, "forbidden", "Admin endpoints need a valid token"}
+)
+
+// Maps the errors of the search and algorithm packages to their response
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	var notFound *search.ElementNotFoundError
+	var invalid *algorithm.InvalidParameterError
+
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.As(err, &notFound):
+		if notFound.Name == "" {
+			return &apiError{http.StatusNotFound, "element_not_found", fmt.Sprintf("Element with ID %d not found", notFound.ID)}
+		}
+		return &apiError{http.StatusNotFound, "element_not_found", fmt.Sprintf("Element '%s' not found", notFound.Name)}
+	case errors.As(err, &invalid):
+		return invalidParameter("%s%s parameter %s", strings.ToUpper(invalid.Parameter[:1]), invalid.Parameter[1:], invalid.Message)
+	case errors.Is(err, algorithm.ErrUnknownAlgorithm):
+		return &apiError{http.StatusBadRequest, "invalid_algorithm", "Algo parameter must be " + quotedList(algorithm.Algorithms())}
+	case errors.Is(err, algorithm.ErrBaseElement):
+		return &apiError{http.StatusBadRequest, "base_element", "Base elements have no recipe"}
+	case errors.Is(err, algorithm.ErrUnreachable):
+		message := err.Error()
+		return &apiError{http.StatusUnprocessableEntity, "unreachable", strings.ToUpper(message[:1]) + message[1:]}
+	case errors.Is(err, algorithm.ErrTimeout):
+		return &apiError{http.StatusGatewayTimeout, "timeout", "The search took too long, try a smaller max or another algorithm"}
+	}
+	return &apiError{http.StatusInternalServerError, "internal_error", "Internal server error"}
+}
+
+// Writes the response of the last error a handler added with c.Error,
+// unless it already wrote one or the client is gone
+func handleErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		apiErr := toAPIError(err)
+		if apiErr.Status == http.StatusInternalServerError {
+			fmt.Println("Error:", err)
+		}
+		c.JSON(apiErr.Status, ErrorResponse{
+			Error:   true,
+			Type:    apiErr.Type,
+			Message: apiErr.Message,
+		})
+	}
+}
+
+// Stops the handler chain with err, which handleErrors turns into a response
+func abortWithError(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"backend/render"
+	"backend/search"
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/graph?format=gexf&element=Brick&radius=2
+func handleGraphExport(datasets *search.GraphStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request GraphRequest
+		if err := c.ShouldBindQuery(&request); err != nil {
+			c.Error(invalidParameter("Invalid query: %v", err))
+			return
+		}
+
+		snapshot := datasets.Current()
+		subgraph := render.WholeGraph(snapshot.Graph)
+		filename := "recipes"
+		if request.Element != "" {
+			center, err := search.GetElementByName(snapshot.Graph, request.Element)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			subgraph = render.Neighborhood(snapshot.Graph, center, request.Radius)
+			filename = center.Name
+		}
+
+		format, _ := render.LookupGraph(request.Format)
+		var body bytes.Buffer
+		if err := format.Write(&body, subgraph); err != nil {
+			c.Error(err)
+			return
+		}
+		c.Header("Content-Disposition", `inline; filename="`+filename+"."+format.Extension+`"`)
+		c.Data(http.StatusOK, format.ContentType+"; charset=utf-8", body.Bytes())
+	}
+}
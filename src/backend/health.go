@@ -0,0 +1,104 @@
+package main
+
+import (
+	"backend/search"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// What the probes report: whether the recipe dataset is loaded and whether
+// the server is about to stop
+type serverHealth struct {
+	datasets *search.GraphStore
+
+	mu           sync.RWMutex
+	startTime    time.Time
+	loadError    string // Of the last load or reload, "" once it succeeded
+	shuttingDown bool
+}
+
+func newServerHealth(datasets *search.GraphStore) *serverHealth {
+	return &serverHealth{datasets: datasets, startTime: time.Now()}
+}
+
+// Pass nil once a load succeeded
+func (h *serverHealth) setLoadError(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		h.loadError = ""
+	} else {
+		h.loadError = err.Error()
+	}
+}
+
+func (h *serverHealth) setShuttingDown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.shuttingDown = true
+}
+
+func (h *serverHealth) isLoaded() bool {
+	return h.datasets.Current() != nil
+}
+
+func (h *serverHealth) datasetStatus() DatasetStatus {
+	dataset := DatasetStatus{Error: h.loadError}
+	if snapshot := h.datasets.Current(); snapshot != nil {
+		dataset.Loaded = true
+		dataset.Version = snapshot.Version
+		dataset.Elements = snapshot.Elements
+		dataset.LoadedAt = &snapshot.LoadedAt
+	}
+	return dataset
+}
+
+// GET /healthz. The process is up and serving, whatever the dataset
+func (h *serverHealth) handleLiveness(c *gin.Context) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	c.JSON(http.StatusOK, HealthResponse{
+		Status:  "ok",
+		Uptime:  time.Since(h.startTime).Round(time.Second).String(),
+		Dataset: h.datasetStatus(),
+	})
+}
+
+// GET /readyz. 503 until the dataset is loaded and again once shutting down
+func (h *serverHealth) handleReadiness(c *gin.Context) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status := "ready"
+	code := http.StatusOK
+	switch {
+	case h.shuttingDown:
+		status = "shutting_down"
+		code = http.StatusServiceUnavailable
+	case !h.isLoaded() && h.loadError != "":
+		status = "load_failed"
+		code = http.StatusServiceUnavailable
+	case !h.isLoaded():
+		status = "loading"
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, HealthResponse{
+		Status:  status,
+		Dataset: h.datasetStatus(),
+	})
+}
+
+// Rejects requests that need the recipe graph while it is not loaded yet
+func (h *serverHealth) requireDataset() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.isLoaded() {
+			abortWithError(c, errDatasetNotReady)
+			return
+		}
+		c.Next()
+	}
+}
@@ -2,182 +2,249 @@ package main
 
 import (
 	"backend/algorithm"
+	"backend/config"
+	"backend/metrics"
+	"backend/render"
 	"backend/scraping"
 	"backend/search"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
-	"strconv"
-	"strings"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	if err := scraping.ScrapeRecipes(false); err != nil {
-		panic(err)
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
-
-	recipes, err := scraping.GetScrapedRecipesJSON()
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, "Invalid configuration:", err)
+		os.Exit(2)
 	}
 
-	var graph search.RecipeGraph
-	if err := search.ConstructRecipeGraph(recipes, &graph); err != nil {
-		panic(err)
-	}
+	scraping.SetResultPath(cfg.Dataset.RecipesPath)
+	scraping.SetWikiURL(cfg.Dataset.WikiURL)
+	algorithm.BFSThreads = cfg.Search.BFSThreads
+	algorithm.BFSMaxIterations = cfg.Search.BFSMaxIterations
+	algorithm.SetCacheSize(cfg.Search.CacheSize)
+	algorithm.SetElementCacheSize(cfg.Search.ElementCacheSize)
+
+	// Cancelled on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Serve the probes right away, the search endpoints answer 503 until
+	// the dataset is loaded. Every request reads the graph from the store,
+	// so a reload never changes the graph under a running search
+	datasets := search.NewGraphStore()
+	health := newServerHealth(datasets)
+	loader := &datasetLoader{scrapeIcons: cfg.Dataset.ScrapeIcons, datasets: datasets, health: health}
+	go func() {
+		if _, _, err := loader.load(cfg.Dataset.ScrapeAtStartup); err != nil {
+			fmt.Println("Loading the dataset failed:", err)
+		}
+	}()
+	go loader.reloadOnSIGHUP(ctx)
 
 	r := gin.Default()
-	r.SetTrustedProxies([]string{"127.0.0.1"})
+	r.SetTrustedProxies(cfg.Server.TrustedProxies)
 	r.Use(cors.New(cors.Config{
-		AllowOrigins: []string{"http://localhost:3000"},
-		AllowMethods: []string{"GET"},
+		AllowOrigins: cfg.Server.CORSOrigins,
+		AllowMethods: []string{"GET", "POST"},
 		AllowHeaders: []string{"Content-Type"},
 	}))
-
-	// http://localhost:8080/api/recipe?element=Acid%20Rain&algo=bfs|dfs
-	r.GET("/api/recipe", func(c *gin.Context) {
-		algorithm.ResetCaches()
-		element := c.Query("element")
-		algo := strings.ToLower(c.DefaultQuery("algo", "bfs"))
-
-		if element == "" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   true,
-				"type":    "missing_parameter",
-				"message": "Element parameter is required",
-			})
-			return
+	r.Use(metrics.Middleware())
+	r.Use(handleErrors())
+
+	// Every query is validated against the parameters of its operation
+	doc := newOpenAPI(cfg.Search.MaxPaths)
+	operation := func(path string) *Operation {
+		if item := doc.Paths[path]; item.Get != nil {
+			return item.Get
 		}
+		return doc.Paths[path].Post
+	}
 
-		// find the node
-		node, err := search.GetElementByName(&graph, element)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   true,
-				"type":    "element_not_found",
-				"message": fmt.Sprintf("Element '%s' not found", element),
-			})
+	r.GET("/openapi.json", handleOpenAPI(doc))
+	r.GET("/metrics", metrics.Handler())
+	r.GET("/healthz", health.handleLiveness)
+	r.GET("/readyz", health.handleReadiness)
+	r.POST("/admin/reload", requireAdmin(cfg.Server.AdminToken), validateQuery(operation("/admin/reload")), loader.handleReload)
+
+	api := r.Group("/api", health.requireDataset())
+
+	// http://localhost:8080/api/recipe?element=Acid%20Rain&algo=bfs|dfs|bidirectional|iddfs|astar&maxDepth=5
+	api.GET("/recipe", validateQuery(operation("/api/recipe")), func(c *gin.Context) {
+		var request SearchRequest
+		if err := c.ShouldBindQuery(&request); err != nil {
+			c.Error(invalidParameter("Invalid query: %v", err))
 			return
 		}
+		request.Max = 1
+		respondSearch(c, datasets.Current().Graph, request, cfg.Search.Timeout)
+	})
 
-		switch algo {
-		case "bfs":
-			big, visitedCount := algorithm.ReverseBFS(node, 1)
-			paths := algorithm.ExpandPaths(*big, element, 1)
-
-			c.JSON(http.StatusOK, gin.H{
-				"error": false,
-				"data": gin.H{
-					"algo":         "bfs",
-					"element":      element,
-					"paths":        paths,          // ← what your frontend expects
-					"visitedNodes": visitedCount,
-				},
-			})
-		case "dfs":
-			var nodeVisited int
-			result := algorithm.DFS(node, &graph, 1, &nodeVisited)
-			log.Printf("Jumlah node yang dikunjungi: %d\n", nodeVisited)
-
-			if len(result) > 0 {
-				c.JSON(http.StatusOK, gin.H{
-					"error": false,
-					"data": gin.H{
-						"nodes":        result[0],
-						"visitedNodes": nodeVisited,
-					},
-				})
-			}
-		default:
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   true,
-				"type":    "invalid_algorithm",
-				"message": "Algorithm must be 'bfs' or 'dfs'",
-			})
+	// http://localhost:8080/api/recipes?element=Brick&algo=dfs&max=5
+	api.GET("/recipes", validateQuery(operation("/api/recipes")), func(c *gin.Context) {
+		var request SearchRequest
+		if err := c.ShouldBindQuery(&request); err != nil {
+			c.Error(invalidParameter("Invalid query: %v", err))
+			return
 		}
+		respondSearch(c, datasets.Current().Graph, request, cfg.Search.Timeout)
 	})
 
-	r.GET("/api/recipes", func(c *gin.Context) {
-		element := c.Query("element")
-		algo := strings.ToLower(c.DefaultQuery("algo", "bfs"))
+	// http://localhost:8080/api/graph?format=cytoscape|gexf|dot&element=Brick&radius=2
+	api.GET("/graph", validateQuery(operation("/api/graph")), handleGraphExport(datasets))
 
-		if element == "" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   true,
-				"type":    "missing_parameter",
-				"message": "Element parameter is required",
-			})
-			return
-		}
+	// http://localhost:8080/api/compare?element=Brick&max=3
+	api.GET("/compare", validateQuery(operation("/api/compare")), handleCompare(datasets, cfg.Search.Timeout))
+
+	// http://localhost:8080/api/analytics?top=20
+	api.GET("/analytics", validateQuery(operation("/api/analytics")), handleAnalytics(datasets))
+
+	// http://localhost:8080/api/leaderboard?sort=dominates|minimalTrees|centrality|descendants&top=20
+	api.GET("/leaderboard", validateQuery(operation("/api/leaderboard")), handleLeaderboard(datasets))
 
-		max, _ := strconv.Atoi(c.DefaultQuery("max", "5"))
-		if max <= 0 {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   true,
-				"type":    "invalid_parameter",
-				"message": "Max parameter must be greater than 0",
-			})
+	// http://localhost:8080/api/dominators?element=Human&check=Life
+	api.GET("/dominators", validateQuery(operation("/api/dominators")), handleDominators(datasets))
+
+	// Body {"tree": {...}, "owned": ["Stone"]}, the tree in the format of /api/recipes
+	api.POST("/validate", handleValidate(datasets))
+
+	// Server-Sent Events, one event per search step
+	// http://localhost:8080/api/recipes/stream?element=Brick&algo=bfs&max=3
+	api.GET("/recipes/stream", validateQuery(operation("/api/recipes/stream")), func(c *gin.Context) {
+		var request SearchRequest
+		if err := c.ShouldBindQuery(&request); err != nil {
+			c.Error(invalidParameter("Invalid query: %v", err))
 			return
 		}
-
-		node, err := search.GetElementByName(&graph, element)
+		graph := datasets.Current().Graph
+		node, err := search.GetElementByName(graph, request.Element)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   true,
-				"type":    "element_not_found",
-				"message": fmt.Sprintf("Element '%s' not found", element),
-			})
+			c.Error(err)
 			return
 		}
 
-		algorithm.ResetCaches()
-		
-		switch algo {
-		case "bfs":
-			big, visited := algorithm.ReverseBFS(node, 1)
-			//print big in terminal
+		// Like respondSearch, the search stops at the timeout
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Search.Timeout)
+		defer cancel()
+		events := make(chan algorithm.SearchEvent, 64)
+		observer := algorithm.NewSearchObserver(ctx, events)
+		go algorithm.StreamSearch(request.Algo, node, graph, request.Max, observer)
+
+		c.Stream(func(w io.Writer) bool {
+			event, ok := <-events
+			if !ok {
+				return false
+			}
+			if event.Type == algorithm.EventFinished && event.Stats != nil {
+				metrics.ObserveSearch(request.Algo, *event.Stats)
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		})
+	})
+
+	api.GET("/session", handleSearchSession(ctx, datasets, cfg.Server.CORSOrigins))
 
-			log.Printf("%+v", big)
-			p := algorithm.ExpandPaths(*big, element, max)
+	server := &http.Server{
+		Addr:         cfg.Server.Addr(),
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+	serverError := make(chan error, 1)
+	go func() {
+		serverError <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverError:
+		fmt.Fprintln(os.Stderr, "Server stopped:", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	stop()
+
+	// Stop accepting requests and let the running searches finish
+	fmt.Println("Shutting down, waiting for running searches...")
+	health.setShuttingDown()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		fmt.Fprintln(os.Stderr, "Forcing shutdown:", err)
+		server.Close()
+	}
+}
 
-			if len(p) > max {
-				p = p[:max]
-			}
+// Runs the search of request and writes its trees. Multiple recipes are
+// deduplicated
+func respondSearch(c *gin.Context, graph *search.RecipeGraph, request SearchRequest, timeout time.Duration) {
+	node, err := search.GetElementByName(graph, request.Element)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-			c.JSON(http.StatusOK, gin.H{
-				"error": false,
-				"data": gin.H{
-					"algo":         "bfs",
-					"element":      element,
-					"paths":        p,
-					"visitedNodes": visited,
-				},
-			})
-		case "dfs":
-			var nodeVisited int
-			results := algorithm.DFS(node, &graph, max, &nodeVisited)
-
-			c.JSON(http.StatusOK, gin.H{
-				"error": false,
-				"data": gin.H{
-					"element":      element,
-					"algo":         algo,
-					"paths":        results,
-					"visitedNodes": nodeVisited,
-				},
-			})
-		default:
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   true,
-				"type":    "invalid_algorithm",
-				"message": "Algorithm must be 'bfs' or 'dfs'",
-			})
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	result, err := algorithm.Search(ctx, request.Algo, node, graph, algorithm.SearchOptions{
+		MaxPaths: request.Max,
+		MaxDepth: request.MaxDepth,
+		Dedup:    request.Max > 1,
+	})
+	if result != nil {
+		metrics.ObserveSearch(request.Algo, result.Stats)
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// format=dot, mermaid, text or ascii answer with the rendered trees only
+	if format, ok := render.Lookup(request.Format); ok {
+		var body bytes.Buffer
+		if err := format.Write(&body, result.Trees); err != nil {
+			c.Error(err)
 			return
 		}
-	})
+		c.Data(http.StatusOK, format.ContentType+"; charset=utf-8", body.Bytes())
+		return
+	}
 
-	r.Run(":8080")
+	data := SearchData{
+		Algo:         request.Algo,
+		Element:      request.Element,
+		Paths:        result.Trees,
+		VisitedNodes: result.VisitedNodes,
+		Stats:        result.Stats,
+	}
+	if request.Max > 1 {
+		data.Duplicates = &result.Duplicates
+	}
+	switch request.Algo {
+	case "bidirectional":
+		data.VisitedForward = &result.Visits.Forward
+		data.VisitedBackward = &result.Visits.Backward
+	case "iddfs":
+		data.Depth = &result.Depth
+	}
+	c.JSON(http.StatusOK, SearchResponse{
+		Error: false,
+		Data:  data,
+	})
 }
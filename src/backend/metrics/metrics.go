@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"backend/algorithm"
+	"backend/search"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var registry = NewRegistry()
+
+var (
+	requestsTotal = registry.NewCounterVec(
+		"alchemy_http_requests_total",
+		"HTTP requests handled, by endpoint, algorithm and status code",
+		"endpoint", "algorithm", "status",
+	)
+	requestDuration = registry.NewHistogramVec(
+		"alchemy_http_request_duration_seconds",
+		"Time spent handling HTTP requests, by endpoint, algorithm and status code",
+		[]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		"endpoint", "algorithm", "status",
+	)
+	searchDuration = registry.NewHistogramVec(
+		"alchemy_search_duration_seconds",
+		"Time spent searching recipe trees, by algorithm",
+		[]float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10},
+		"algorithm",
+	)
+	searchNodesVisited = registry.NewHistogramVec(
+		"alchemy_search_nodes_visited",
+		"Nodes visited by one search, by algorithm",
+		[]float64{1, 10, 50, 100, 500, 1000, 5000, 10000, 50000, 100000},
+		"algorithm",
+	)
+	searchesCached = registry.NewCounterVec(
+		"alchemy_search_cached_total",
+		"Searches answered from the subtree cache, by algorithm",
+		"algorithm",
+	)
+	graphElements = registry.NewGaugeVec(
+		"alchemy_graph_elements",
+		"Elements in the loaded recipe graph, without the sentinel",
+	)
+	graphRecipes = registry.NewGaugeVec(
+		"alchemy_graph_recipes",
+		"Recipes in the loaded recipe graph, without the primordial ones",
+	)
+	datasetInfo = registry.NewGaugeVec(
+		"alchemy_dataset_info",
+		"Version of the loaded recipe dataset. Always 1",
+		"version",
+	)
+	datasetLoads = registry.NewCounterVec(
+		"alchemy_dataset_loads_total",
+		"Loads and reloads of the recipe dataset, by result",
+		"result",
+	)
+)
+
+func init() {
+	registry.NewCounterFunc("alchemy_cache_hits_total", "Subtree cache hits", func() float64 {
+		return float64(algorithm.GetCacheStatistic().Hits)
+	})
+	registry.NewCounterFunc("alchemy_cache_misses_total", "Subtree cache misses", func() float64 {
+		return float64(algorithm.GetCacheStatistic().Misses)
+	})
+	registry.NewGaugeFunc("alchemy_cache_hit_ratio", "Subtree cache hits over lookups since the last reset", func() float64 {
+		statistic := algorithm.GetCacheStatistic()
+		if statistic.Hits+statistic.Misses == 0 {
+			return 0
+		}
+		return float64(statistic.Hits) / float64(statistic.Hits+statistic.Misses)
+	})
+	registry.NewGaugeFunc("alchemy_cache_entries", "Recipe trees held by the subtree cache", func() float64 {
+		return float64(algorithm.GetCacheStatistic().Size)
+	})
+	registry.NewGaugeFunc("alchemy_cache_capacity", "Maximum number of recipe trees held by the subtree cache", func() float64 {
+		return float64(algorithm.GetCacheStatistic().Capacity)
+	})
+}
+
+// Records the duration and status of every request
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+		c.Next()
+
+		endpoint := c.FullPath()
+		if endpoint == "" {
+			endpoint = "unmatched"
+		}
+		algo := algorithmLabel(c.Query("algo"))
+		status := strconv.Itoa(c.Writer.Status())
+
+		requestsTotal.Inc(endpoint, algo, status)
+		requestDuration.Observe(time.Since(startTime).Seconds(), endpoint, algo, status)
+	}
+}
+
+// Keeps the label set bounded whatever clients send
+func algorithmLabel(algo string) string {
+	algo = strings.ToLower(algo)
+	if algo == "" || algorithm.IsAlgorithm(algo) {
+		return algo
+	}
+	return "invalid"
+}
+
+// Records the statistics of a finished search
+func ObserveSearch(algo string, stats algorithm.SearchStats) {
+	algo = algorithmLabel(algo)
+	searchDuration.Observe(stats.DurationMs/1000, algo)
+	searchNodesVisited.Observe(float64(stats.NodesVisited), algo)
+	if stats.Cached {
+		searchesCached.Inc(algo)
+	}
+}
+
+// Records the size and version of the graph the server answers from
+func SetGraph(graph *search.RecipeGraph, version string) {
+	recipes := 0
+	for _, element := range graph.Elements {
+		for _, recipe := range element.Recipes {
+			if len(recipe) == 2 && recipe[0].Name == "" && recipe[1].Name == "" {
+				continue
+			}
+			recipes++
+		}
+	}
+
+	graphElements.Set(float64(len(graph.Elements) - 1))
+	graphRecipes.Set(float64(recipes))
+	datasetInfo.Reset()
+	datasetInfo.Set(1, version)
+}
+
+func ObserveDatasetLoad(success bool) {
+	if success {
+		datasetLoads.Inc("success")
+	} else {
+		datasetLoads.Inc("failure")
+	}
+}
+
+// GET /metrics in the Prometheus text format
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		registry.Write(c.Writer)
+	}
+}
@@ -0,0 +1,137 @@
+package metrics_test
+
+import (
+	"backend/metrics"
+	"backend/search/graphtest"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func export(registry *metrics.Registry) string {
+	var out strings.Builder
+	registry.Write(&out)
+	return out.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	registry := metrics.NewRegistry()
+	counter := registry.NewCounterVec("requests_total", "Requests handled", "endpoint", "status")
+	counter.Inc("/b", "200")
+	counter.Add(2.5, "/a", "404")
+	counter.Inc("/b", "200")
+
+	want := `# HELP requests_total Requests handled
+# TYPE requests_total counter
+requests_total{endpoint="/a",status="404"} 2.5
+requests_total{endpoint="/b",status="200"} 2
+`
+	if got := export(registry); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	registry := metrics.NewRegistry()
+	// The buckets are sorted whatever order they are given in
+	histogram := registry.NewHistogramVec("duration_seconds", "Time spent", []float64{1, 0.5}, "algorithm")
+	for _, value := range []float64{0.25, 0.5, 0.75, 3} {
+		histogram.Observe(value, "bfs")
+	}
+
+	want := `# HELP duration_seconds Time spent
+# TYPE duration_seconds histogram
+duration_seconds_bucket{algorithm="bfs",le="0.5"} 2
+duration_seconds_bucket{algorithm="bfs",le="1"} 3
+duration_seconds_bucket{algorithm="bfs",le="+Inf"} 4
+duration_seconds_sum{algorithm="bfs"} 4.5
+duration_seconds_count{algorithm="bfs"} 4
+`
+	if got := export(registry); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGaugesAndFuncs(t *testing.T) {
+	registry := metrics.NewRegistry()
+	info := registry.NewGaugeVec("dataset_info", "Loaded dataset", "version")
+	info.Set(1, "old")
+	info.Reset()
+	info.Set(1, "new")
+	registry.NewGaugeFunc("ratio", "Some ratio", func() float64 { return math.NaN() })
+	registry.NewCounterFunc("hits_total", "Hits", func() float64 { return math.Inf(1) })
+
+	want := `# HELP dataset_info Loaded dataset
+# TYPE dataset_info gauge
+dataset_info{version="new"} 1
+# HELP ratio Some ratio
+# TYPE ratio gauge
+ratio NaN
+# HELP hits_total Hits
+# TYPE hits_total counter
+hits_total +Inf
+`
+	if got := export(registry); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	registry := metrics.NewRegistry()
+	counter := registry.NewCounterVec("escaped_total", "Help with a \\ and a\nnewline", "value")
+	counter.Inc("a \"quoted\" \\ value\non two lines")
+
+	want := `# HELP escaped_total Help with a \\ and a\nnewline
+# TYPE escaped_total counter
+escaped_total{value="a \"quoted\" \\ value\non two lines"} 1
+`
+	if got := export(registry); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWrongLabelCount(t *testing.T) {
+	registry := metrics.NewRegistry()
+	counter := registry.NewCounterVec("labelled_total", "Labelled", "a", "b")
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic with one label value out of two")
+		}
+	}()
+	counter.Inc("x")
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics.SetGraph(graphtest.Diamond().Graph(t), "test")
+	metrics.ObserveDatasetLoad(true)
+
+	r := gin.New()
+	r.GET("/metrics", metrics.Handler())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type %q", contentType)
+	}
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE alchemy_http_requests_total counter",
+		"# TYPE alchemy_search_duration_seconds histogram",
+		"alchemy_graph_elements 8",
+		`alchemy_dataset_info{version="test"} 1`,
+		`alchemy_dataset_loads_total{result="success"} 1`,
+		"# TYPE alchemy_cache_hits_total counter",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("no line %q in\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/analytics"
+	"backend/render"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The subset of OpenAPI 3.0 this API needs
+
+type OpenAPI struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       OpenAPIInfo          `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components OpenAPIComponents    `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type PathItem struct {
+	Get  *Operation `json:"get,omitempty"`
+	Post *Operation `json:"post,omitempty"`
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+	ErrorType   string  `json:"x-error-type,omitempty"` // Error type when the value is invalid, invalid_parameter by default
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Builds the document served at /openapi.json. Response schemas are
+// generated from the response structs, so they cannot drift from what the
+// handlers send. maxPaths is the configured upper bound of max
+func newOpenAPI(maxPaths int) *OpenAPI {
+	schemas := make(map[string]*Schema)
+	ref := func(value any) *Schema { return schemaOf(reflect.TypeOf(value), schemas) }
+	jsonBody := func(description string, value any) *Response {
+		return &Response{Description: description, Content: map[string]*MediaType{"application/json": {Schema: ref(value)}}}
+	}
+	errorBody := func(description string) *Response { return jsonBody(description, ErrorResponse{}) }
+
+	element := &Parameter{Name: "element", In: "query", Required: true, Description: "Name of the element to craft, e.g. Brick", Schema: &Schema{Type: "string"}}
+	algo := &Parameter{Name: "algo", In: "query", Description: "Search algorithm, case insensitive", ErrorType: "invalid_algorithm",
+		Schema: &Schema{Type: "string", Enum: algorithm.Algorithms(), Default: "bfs"}}
+	maxDepth := &Parameter{Name: "maxDepth", In: "query", Description: "IDDFS only. Deepest tree searched, 0 for the tier of the element",
+		Schema: &Schema{Type: "integer", Minimum: intPtr(0), Default: 0}}
+	max := func(fallback int) *Parameter {
+		return &Parameter{Name: "max", In: "query", Description: "Number of recipes to find",
+			Schema: &Schema{Type: "integer", Minimum: intPtr(1), Maximum: intPtr(maxPaths), Default: fallback}}
+	}
+	format := &Parameter{Name: "format", In: "query", Description: "json, or the trees rendered as Graphviz DOT, a Mermaid flowchart or an indented text tree",
+		Schema: &Schema{Type: "string", Enum: append([]string{"json"}, render.Formats()...), Default: "json"}}
+	// The JSON response, or the rendered trees when format is not json
+	searchBody := func(description string) *Response {
+		response := jsonBody(description, SearchResponse{})
+		for _, name := range render.Formats() {
+			rendered, _ := render.Lookup(name)
+			response.Content[rendered.ContentType] = &MediaType{Schema: &Schema{Type: "string"}}
+		}
+		return response
+	}
+	searchErrors := map[string]*Response{
+		"400": errorBody("Invalid or missing parameter, or unknown algorithm"),
+		"404": errorBody("Element not found"),
+		"422": errorBody("The element cannot be crafted"),
+		"503": errorBody("The recipe dataset is not loaded yet"),
+		"504": errorBody("The search took longer than the configured timeout"),
+	}
+	withErrors := func(responses map[string]*Response, errors map[string]*Response) map[string]*Response {
+		for code, response := range errors {
+			responses[code] = response
+		}
+		return responses
+	}
+
+	doc := &OpenAPI{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:       "Little Alchemy 2 recipe finder",
+			Version:     "1.0.0",
+			Description: "Finds recipe trees of Little Alchemy 2 elements scraped from the wiki",
+		},
+		Paths: map[string]*PathItem{
+			"/api/recipe": {Get: &Operation{
+				OperationID: "findRecipe",
+				Summary:     "Find one recipe tree",
+				Parameters:  []*Parameter{element, algo, maxDepth, format},
+				Responses:   withErrors(map[string]*Response{"200": searchBody("The recipe tree")}, searchErrors),
+			}},
+			"/api/recipes": {Get: &Operation{
+				OperationID: "findRecipes",
+				Summary:     "Find several distinct recipe trees",
+				Parameters:  []*Parameter{element, algo, max(5), maxDepth, format},
+				Responses:   withErrors(map[string]*Response{"200": searchBody("The recipe trees")}, searchErrors),
+			}},
+			"/api/recipes/stream": {Get: &Operation{
+				OperationID: "streamRecipes",
+				Summary:     "Stream the progress of a search as Server-Sent Events",
+				Parameters:  []*Parameter{element, algo, max(1)},
+				Responses: withErrors(map[string]*Response{"200": {
+					Description: "One event per search step, named after its type",
+					Content:     map[string]*MediaType{"text/event-stream": {Schema: ref(algorithm.SearchEvent{})}},
+				}}, searchErrors),
+			}},
+			"/api/compare": {Get: &Operation{
+				OperationID: "compareAlgorithms",
+				Summary:     "Run every algorithm with the same options side by side",
+				Description: "The algorithms run one after the other, each with the search timeout and without the shared caches. " +
+					"A failed search is reported in its result, the request still succeeds",
+				Parameters: []*Parameter{element, max(1), maxDepth},
+				Responses: map[string]*Response{
+					"200": jsonBody("One result per algorithm", CompareResponse{}),
+					"400": errorBody("Invalid or missing parameter"),
+					"404": errorBody("Element not found"),
+					"503": errorBody("The recipe dataset is not loaded yet"),
+				},
+			}},
+			"/api/graph": {Get: &Operation{
+				OperationID: "exportGraph",
+				Summary:     "Export the recipe graph, or the part around an element",
+				Description: "Nodes are elements with tier, icon and base attributes. Every recipe is a hyperedge written as two edges, " +
+					"one from each ingredient to the result, sharing the recipe attribute",
+				Parameters: []*Parameter{
+					{Name: "format", In: "query", Description: "Cytoscape JSON, GEXF for Gephi or Graphviz DOT",
+						Schema: &Schema{Type: "string", Enum: render.GraphFormats(), Default: "cytoscape"}},
+					{Name: "element", In: "query", Description: "Only export the elements around this one, the whole graph when empty",
+						Schema: &Schema{Type: "string"}},
+					{Name: "radius", In: "query", Description: "Number of recipes away from element an exported element may be",
+						Schema: &Schema{Type: "integer", Minimum: intPtr(0), Maximum: intPtr(10), Default: 1}},
+				},
+				Responses: map[string]*Response{
+					"200": {Description: "The graph in the requested format", Content: map[string]*MediaType{
+						"application/json":     {Schema: &Schema{Type: "object"}},
+						"application/gexf+xml": {Schema: &Schema{Type: "string"}},
+						"text/vnd.graphviz":    {Schema: &Schema{Type: "string"}},
+					}},
+					"400": errorBody("Invalid parameter"),
+					"404": errorBody("Element not found"),
+					"503": errorBody("The recipe dataset is not loaded yet"),
+				},
+			}},
+			"/api/analytics": {Get: &Operation{
+				OperationID: "analyzeGraph",
+				Summary:     "Reachability, dead ends, degree rankings and recipes discarded by the tier rule",
+				Parameters: []*Parameter{{Name: "top", In: "query", Description: "Length of the degree rankings",
+					Schema: &Schema{Type: "integer", Minimum: intPtr(1), Maximum: intPtr(1000), Default: 20}}},
+				Responses: map[string]*Response{
+					"200": jsonBody("The report", AnalyticsResponse{}),
+					"400": errorBody("Invalid parameter"),
+					"503": errorBody("The recipe dataset is not loaded yet"),
+				},
+			}},
+			"/api/leaderboard": {Get: &Operation{
+				OperationID: "elementLeaderboard",
+				Summary:     "Rank the elements by how essential they are to the others",
+				Description: "Only the recipes the search algorithms use count. Computed once per dataset",
+				Parameters: []*Parameter{
+					{Name: "sort", In: "query", Description: "Ranking key, highest first",
+						Schema: &Schema{Type: "string", Enum: analytics.ImportanceKeys, Default: "dominates"}},
+					{Name: "top", In: "query", Description: "Number of elements returned",
+						Schema: &Schema{Type: "integer", Minimum: intPtr(1), Maximum: intPtr(1000), Default: 20}},
+					{Name: "includeBase", In: "query", Description: "Also rank Air, Earth, Fire and Water",
+						Schema: &Schema{Type: "boolean", Default: false}},
+				},
+				Responses: map[string]*Response{
+					"200": jsonBody("The leaderboard", LeaderboardResponse{}),
+					"400": errorBody("Invalid parameter"),
+					"503": errorBody("The recipe dataset is not loaded yet"),
+				},
+			}},
+			"/api/dominators": {Get: &Operation{
+				OperationID: "elementDominators",
+				Summary:     "Elements every recipe tree of an element goes through",
+				Description: "Only the recipes the search algorithms use count. With check, tells whether that element is unavoidable " +
+					"and otherwise gives a tree without it",
+				Parameters: []*Parameter{element,
+					{Name: "check", In: "query", Description: "Element to check, e.g. Life for element=Human", Schema: &Schema{Type: "string"}}},
+				Responses: map[string]*Response{
+					"200": jsonBody("The unavoidable elements", DominatorsResponse{}),
+					"400": errorBody("Missing parameter or base element"),
+					"404": errorBody("Element not found"),
+					"422": errorBody("The element cannot be crafted"),
+					"503": errorBody("The recipe dataset is not loaded yet"),
+				},
+			}},
+			"/api/validate": {Post: &Operation{
+				OperationID: "validateTree",
+				Summary:     "Check a recipe tree submitted by the user",
+				Description: "The root must be the target, every recipe one of the element and every leaf a base or owned element. " +
+					"Invalid trees are still a 200 listing the violations",
+				RequestBody: &RequestBody{
+					Description: "The tree in the format of /api/recipes",
+					Required:    true,
+					Content:     map[string]*MediaType{"application/json": {Schema: ref(ValidateRequest{})}},
+				},
+				Responses: map[string]*Response{
+					"200": jsonBody("Whether the tree is valid", ValidateResponse{}),
+					"400": errorBody("The body is not a tree"),
+					"404": errorBody("Owned element not found"),
+					"503": errorBody("The recipe dataset is not loaded yet"),
+				},
+			}},
+			"/api/session": {Get: &Operation{
+				OperationID: "searchSession",
+				Summary:     "Step through a search over a WebSocket",
+				Responses: map[string]*Response{
+					"101": {Description: "Switching to the WebSocket protocol. See SessionCommand and SessionMessage"},
+					"503": errorBody("The recipe dataset is not loaded yet"),
+				},
+			}},
+			"/healthz": {Get: &Operation{
+				OperationID: "liveness",
+				Summary:     "Liveness probe",
+				Responses:   map[string]*Response{"200": jsonBody("The server is up", HealthResponse{})},
+			}},
+			"/readyz": {Get: &Operation{
+				OperationID: "readiness",
+				Summary:     "Readiness probe",
+				Responses: map[string]*Response{
+					"200": jsonBody("The dataset is loaded", HealthResponse{}),
+					"503": jsonBody("Loading, failed to load or shutting down", HealthResponse{}),
+				},
+			}},
+			"/admin/reload": {Post: &Operation{
+				OperationID: "reloadDataset",
+				Summary:     "Reload the recipe dataset, needs the admin token",
+				Parameters: []*Parameter{{Name: "scrape", In: "query", Description: "Scrape the wiki before reloading",
+					Schema: &Schema{Type: "boolean", Default: false}}},
+				Responses: map[string]*Response{
+					"200": jsonBody("The dataset now in use", ReloadResponse{}),
+					"400": errorBody("Invalid parameter"),
+					"403": errorBody("Missing or wrong admin token"),
+					"500": errorBody("The reload failed, the previous dataset is kept"),
+				},
+			}},
+			"/metrics": {Get: &Operation{
+				OperationID: "metrics",
+				Summary:     "Prometheus metrics",
+				Responses: map[string]*Response{"200": {
+					Description: "Prometheus text format 0.0.4",
+					Content:     map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string"}}},
+				}},
+			}},
+		},
+	}
+	// Documented for clients, the session messages are not a response body
+	ref(SessionCommand{})
+	ref(SessionMessage{})
+	doc.Components.Schemas = schemas
+	return doc
+}
+
+func intPtr(n int) *int { return &n }
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// JSON schema of t. Named structs are added to schemas and referenced
+func schemaOf(t reflect.Type, schemas map[string]*Schema) *Schema {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+		if _, done := schemas[t.Name()]; done {
+			return ref
+		}
+		// Registered before the fields so recursive types end in a $ref
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		schemas[t.Name()] = schema
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, omitEmpty, ok := jsonField(field)
+			if !ok {
+				continue
+			}
+			property := schemaOf(field.Type, schemas)
+			// Siblings of $ref are ignored in OpenAPI 3.0
+			if property.Ref == "" {
+				property.Description = field.Tag.Get("description")
+			}
+			schema.Properties[name] = property
+			if !omitEmpty {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		return ref
+	}
+	return &Schema{}
+}
+
+// Name of field in JSON, whether it may be left out, and whether it is encoded at all
+func jsonField(field reflect.StructField) (string, bool, bool) {
+	if !field.IsExported() {
+		return "", false, false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, slices.Contains(strings.Split(options, ","), "omitempty"), true
+}
+
+// Checks the query of every request against the parameters of operation,
+// then rewrites it with the defaults filled in and enums in their
+// canonical case, ready to be bound to a request struct
+func validateQuery(operation *Operation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		normalized := url.Values{}
+		for _, param := range operation.Parameters {
+			if param.In != "query" {
+				continue
+			}
+			value, err := checkParameter(param, query.Get(param.Name))
+			if err != nil {
+				abortWithError(c, err)
+				return
+			}
+			if value != "" {
+				normalized.Set(param.Name, value)
+			}
+		}
+		c.Request.URL.RawQuery = normalized.Encode()
+		c.Next()
+	}
+}
+
+func checkParameter(param *Parameter, value string) (string, error) {
+	label := strings.ToUpper(param.Name[:1]) + param.Name[1:]
+	schema := param.Schema
+
+	if value == "" {
+		if param.Required {
+			return "", missingParameter(label)
+		}
+		if schema.Default == nil {
+			return "", nil
+		}
+		return fmt.Sprint(schema.Default), nil
+	}
+
+	invalid := func(format string, args ...any) error {
+		err := invalidParameter("%s parameter must be "+format, append([]any{label}, args...)...)
+		if param.ErrorType != "" {
+			err.Type = param.ErrorType
+		}
+		return err
+	}
+
+	switch schema.Type {
+	case "integer":
+		n, err := strconv.Atoi(value)
+		tooSmall := schema.Minimum != nil && n < *schema.Minimum
+		tooLarge := schema.Maximum != nil && n > *schema.Maximum
+		if err != nil || tooSmall || tooLarge {
+			switch {
+			case schema.Minimum != nil && schema.Maximum != nil:
+				return "", invalid("a number between %d and %d", *schema.Minimum, *schema.Maximum)
+			case schema.Minimum != nil && *schema.Minimum == 0:
+				return "", invalid("a non-negative number")
+			case schema.Minimum != nil:
+				return "", invalid("a number of at least %d", *schema.Minimum)
+			}
+			return "", invalid("a number")
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "", invalid("true or false")
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		i := slices.IndexFunc(schema.Enum, func(option string) bool { return strings.EqualFold(option, value) })
+		if i < 0 {
+			return "", invalid("%s", quotedList(schema.Enum))
+		}
+		value = schema.Enum[i]
+	}
+	return value, nil
+}
+
+// 'a', 'b' or 'c'
+func quotedList(options []string) string {
+	quoted := make([]string, len(options))
+	for i, option := range options {
+		quoted[i] = "'" + option + "'"
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// GET /openapi.json
+func handleOpenAPI(doc *OpenAPI) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	}
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Cytoscape JSON, loaded by Cytoscape desktop (File > Import > Network) and
+// cytoscape.js
+type cytoscapeGraph struct {
+	FormatVersion string            `json:"format_version"`
+	GeneratedBy   string            `json:"generated_by"`
+	Data          map[string]string `json:"data"`
+	Elements      cytoscapeElements `json:"elements"`
+}
+
+type cytoscapeElements struct {
+	Nodes []cytoscapeNode `json:"nodes"`
+	Edges []cytoscapeEdge `json:"edges"`
+}
+
+type cytoscapeNode struct {
+	Data struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Tier int    `json:"tier"`
+		Icon string `json:"icon"`
+		Base bool   `json:"base"`
+	} `json:"data"`
+}
+
+type cytoscapeEdge struct {
+	Data struct {
+		ID      string `json:"id"`
+		Source  string `json:"source"` // Ingredient
+		Target  string `json:"target"` // Result
+		Recipe  string `json:"recipe"` // Shared by the two edges of a recipe
+		Partner string `json:"partner"`
+	} `json:"data"`
+}
+
+func WriteCytoscape(w io.Writer, graph *Subgraph) error {
+	out := cytoscapeGraph{
+		FormatVersion: "1.0",
+		GeneratedBy:   "alchemy",
+		Data:          map[string]string{"name": "Little Alchemy 2 recipes"},
+		Elements: cytoscapeElements{
+			Nodes: make([]cytoscapeNode, len(graph.Elements)),
+			Edges: make([]cytoscapeEdge, 0, 2*len(graph.Recipes)),
+		},
+	}
+	for i, element := range graph.Elements {
+		node := &out.Elements.Nodes[i]
+		node.Data.ID = nodeID(element.ID)
+		node.Data.Name = element.Name
+		node.Data.Tier = element.Tier
+		node.Data.Icon = iconOf(element)
+		node.Data.Base = isBase(element)
+	}
+	for _, recipe := range graph.Recipes {
+		for i, ingredient := range recipe.Ingredients {
+			var edge cytoscapeEdge
+			edge.Data.ID = recipe.ID + string(rune('a'+i))
+			edge.Data.Source = nodeID(ingredient.ID)
+			edge.Data.Target = nodeID(recipe.Result.ID)
+			edge.Data.Recipe = recipe.ID
+			edge.Data.Partner = recipe.Ingredients[1-i].Name
+			out.Elements.Edges = append(out.Elements.Edges, edge)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
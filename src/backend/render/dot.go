@@ -0,0 +1,56 @@
+package render
+
+import (
+	"backend/algorithm"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Graphviz digraph with a cluster per tree. Arrows go from the ingredients to
+// the element they craft, base elements are filled
+//
+//	dot -Tsvg tree.dot > tree.svg
+func WriteDOT(w io.Writer, trees []*algorithm.RecipeTree) error {
+	var b strings.Builder
+	b.WriteString("digraph recipes {\n")
+	b.WriteString("  rankdir=BT;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+	next := 0
+	for i, tree := range trees {
+		indent := "  "
+		if len(trees) > 1 {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n", i+1)
+			fmt.Fprintf(&b, "    label=%s;\n", dotQuote(fmt.Sprintf("Tree %d: depth %d, %d crafts", i+1, tree.Depth, tree.Crafts)))
+			indent = "    "
+		}
+		writeDOTNode(&b, tree.Root, indent, &next)
+		if len(trees) > 1 {
+			b.WriteString("  }\n")
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Writes node and its ingredients, returns the DOT ID of node
+func writeDOTNode(b *strings.Builder, node *algorithm.TreeNode, indent string, next *int) string {
+	id := fmt.Sprintf("n%d", *next)
+	*next++
+	if len(node.Ingredients) == 0 {
+		fmt.Fprintf(b, "%s%s [label=%s, style=\"rounded,filled\", fillcolor=\"#e8f4e8\"];\n", indent, id, dotQuote(node.Name))
+	} else {
+		fmt.Fprintf(b, "%s%s [label=%s];\n", indent, id, dotQuote(TierLabel(node)))
+	}
+	for _, ingredient := range node.Ingredients {
+		ingredientID := writeDOTNode(b, ingredient, indent, next)
+		fmt.Fprintf(b, "%s%s -> %s;\n", indent, ingredientID, id)
+	}
+	return id
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// GEXF 1.3, the native format of Gephi
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Meta    gexfMeta  `xml:"meta"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfMeta struct {
+	Creator     string `xml:"creator"`
+	Description string `xml:"description"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func WriteGEXF(w io.Writer, graph *Subgraph) error {
+	doc := gexfDocument{
+		Xmlns:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Meta:    gexfMeta{Creator: "alchemy", Description: "Little Alchemy 2 recipes, an edge from every ingredient to its result"},
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Attributes: []gexfAttributes{
+				{"node", []gexfAttribute{{"tier", "tier", "integer"}, {"icon", "icon", "string"}, {"base", "base", "boolean"}}},
+				{"edge", []gexfAttribute{{"recipe", "recipe", "string"}, {"partner", "partner", "string"}}},
+			},
+			Nodes: make([]gexfNode, len(graph.Elements)),
+			Edges: make([]gexfEdge, 0, 2*len(graph.Recipes)),
+		},
+	}
+	for i, element := range graph.Elements {
+		doc.Graph.Nodes[i] = gexfNode{
+			ID:    nodeID(element.ID),
+			Label: element.Name,
+			AttValues: []gexfAttValue{
+				{"tier", strconv.Itoa(element.Tier)},
+				{"icon", iconOf(element)},
+				{"base", strconv.FormatBool(isBase(element))},
+			},
+		}
+	}
+	for _, recipe := range graph.Recipes {
+		for i, ingredient := range recipe.Ingredients {
+			doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+				ID:     recipe.ID + string(rune('a'+i)),
+				Source: nodeID(ingredient.ID),
+				Target: nodeID(recipe.Result.ID),
+				AttValues: []gexfAttValue{
+					{"recipe", recipe.ID},
+					{"partner", recipe.Ingredients[1-i].Name},
+				},
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,129 @@
+package render
+
+import (
+	"backend/scraping"
+	"backend/search"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Part of the recipe graph to export. Every recipe is a hyperedge from its
+// two ingredients to its result, exporters write it as two edges sharing the
+// recipe ID
+type Subgraph struct {
+	Elements []*search.ElementNode // By ID
+	Recipes  []GraphRecipe
+}
+
+type GraphRecipe struct {
+	ID          string // "r<result ID>.<index in Recipes>", stable for one dataset
+	Result      *search.ElementNode
+	Ingredients [2]*search.ElementNode
+}
+
+type GraphFormat struct {
+	Name        string
+	Extension   string
+	ContentType string
+	Write       func(w io.Writer, graph *Subgraph) error
+}
+
+var graphFormats = []GraphFormat{
+	{"cytoscape", "cyjs", "application/json", WriteCytoscape},
+	{"gexf", "gexf", "application/gexf+xml", WriteGEXF},
+	{"dot", "dot", "text/vnd.graphviz", WriteGraphDOT},
+}
+
+func GraphFormats() []string {
+	names := make([]string, len(graphFormats))
+	for i, format := range graphFormats {
+		names[i] = format.Name
+	}
+	return names
+}
+
+func LookupGraph(name string) (GraphFormat, bool) {
+	for _, format := range graphFormats {
+		if format.Name == name {
+			return format, true
+		}
+	}
+	return GraphFormat{}, false
+}
+
+// Every element and recipe
+func WholeGraph(graph *search.RecipeGraph) *Subgraph {
+	included := make(map[*search.ElementNode]bool, len(graph.Elements))
+	for _, element := range graph.Elements[1:] {
+		included[element] = true
+	}
+	return subgraphOf(graph, included)
+}
+
+// Elements at most radius recipes away from center, either as ingredient or
+// as result, and the recipes whose three elements are all included
+func Neighborhood(graph *search.RecipeGraph, center *search.ElementNode, radius int) *Subgraph {
+	root := search.GetRoot(graph)
+	included := map[*search.ElementNode]bool{center: true}
+	frontier := []*search.ElementNode{center}
+	for range radius {
+		next := make([]*search.ElementNode, 0)
+		visit := func(element *search.ElementNode) {
+			if element != root && !included[element] {
+				included[element] = true
+				next = append(next, element)
+			}
+		}
+		for _, element := range frontier {
+			for _, recipe := range element.Recipes {
+				visit(recipe[0])
+				visit(recipe[1])
+			}
+			for _, child := range element.Children {
+				visit(child)
+			}
+		}
+		frontier = next
+	}
+	return subgraphOf(graph, included)
+}
+
+func subgraphOf(graph *search.RecipeGraph, included map[*search.ElementNode]bool) *Subgraph {
+	subgraph := &Subgraph{
+		Elements: make([]*search.ElementNode, 0, len(included)),
+		Recipes:  make([]GraphRecipe, 0),
+	}
+	for _, element := range graph.Elements[1:] {
+		if !included[element] {
+			continue
+		}
+		subgraph.Elements = append(subgraph.Elements, element)
+		for i, recipe := range element.Recipes {
+			// Base elements have one recipe made of the root sentinel
+			if !included[recipe[0]] || !included[recipe[1]] {
+				continue
+			}
+			subgraph.Recipes = append(subgraph.Recipes, GraphRecipe{
+				ID:          fmt.Sprintf("r%d.%d", element.ID, i),
+				Result:      element,
+				Ingredients: [2]*search.ElementNode{recipe[0], recipe[1]},
+			})
+		}
+	}
+	return subgraph
+}
+
+// The scraped icon, otherwise the one the frontend serves for every element
+func iconOf(element *search.ElementNode) string {
+	if element.Icon != "" {
+		return element.Icon
+	}
+	return "/icons/" + element.Name + ".webp"
+}
+
+// Uncraftable elements such as Time also have the empty recipe of the base
+// elements, so only the names tell them apart
+func isBase(element *search.ElementNode) bool {
+	return slices.Contains(scraping.BaseElements, element.Name)
+}
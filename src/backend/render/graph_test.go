@@ -0,0 +1,68 @@
+package render_test
+
+import (
+	"backend/render"
+	"backend/search/graphtest"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Time has the empty recipe of the base elements but cannot be crafted, it
+// is not a base element
+func TestBaseElements(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	subgraph := render.WholeGraph(graph)
+	want := map[string]bool{"Air": true, "Earth": true, "Fire": true, "Water": true, "Time": false}
+
+	var cytoscape strings.Builder
+	if err := render.WriteCytoscape(&cytoscape, subgraph); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Elements struct {
+			Nodes []struct {
+				Data struct {
+					Name string `json:"name"`
+					Base bool   `json:"base"`
+				} `json:"data"`
+			} `json:"nodes"`
+		} `json:"elements"`
+	}
+	if err := json.Unmarshal([]byte(cytoscape.String()), &out); err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, node := range out.Elements.Nodes {
+		base, ok := want[node.Data.Name]
+		if !ok {
+			if node.Data.Base {
+				t.Errorf("cytoscape: %s is base", node.Data.Name)
+			}
+			continue
+		}
+		found++
+		if node.Data.Base != base {
+			t.Errorf("cytoscape: %s has base %t, want %t", node.Data.Name, node.Data.Base, base)
+		}
+	}
+	if found != len(want) {
+		t.Errorf("cytoscape: %d of %d elements found", found, len(want))
+	}
+
+	var dot strings.Builder
+	if err := render.WriteGraphDOT(&dot, subgraph); err != nil {
+		t.Fatal(err)
+	}
+	for name, base := range want {
+		var line string
+		for _, candidate := range strings.Split(dot.String(), "\n") {
+			if strings.Contains(candidate, `label="`+name+`"`) {
+				line = candidate
+			}
+		}
+		if filled := strings.Contains(line, "filled"); filled != base {
+			t.Errorf("dot: %s is filled %t, want %t in %q", name, filled, base, line)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Graphviz digraph with the elements of a tier on one rank. The tier, icon
+// and recipe attributes are ignored by dot but kept by Gephi
+func WriteGraphDOT(w io.Writer, graph *Subgraph) error {
+	var b strings.Builder
+	b.WriteString("digraph recipes {\n")
+	b.WriteString("  rankdir=BT;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+
+	tiers := make(map[int][]string)
+	maxTier := 0
+	for _, element := range graph.Elements {
+		style := ""
+		if isBase(element) {
+			style = ", style=\"rounded,filled\", fillcolor=\"#e8f4e8\""
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, tier=%d, icon=%s%s];\n", nodeID(element.ID), dotQuote(element.Name), element.Tier, dotQuote(iconOf(element)), style)
+		tiers[element.Tier] = append(tiers[element.Tier], nodeID(element.ID))
+		maxTier = max(maxTier, element.Tier)
+	}
+	for tier := 0; tier <= maxTier; tier++ {
+		if len(tiers[tier]) > 0 {
+			fmt.Fprintf(&b, "  { rank=same; %s; }\n", strings.Join(tiers[tier], "; "))
+		}
+	}
+	for _, recipe := range graph.Recipes {
+		for _, ingredient := range recipe.Ingredients {
+			fmt.Fprintf(&b, "  %s -> %s [recipe=%s];\n", nodeID(ingredient.ID), nodeID(recipe.Result.ID), dotQuote(recipe.ID))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func nodeID(id int) string {
+	return fmt.Sprintf("n%d", id)
+}
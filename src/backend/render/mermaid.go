@@ -0,0 +1,47 @@
+package render
+
+import (
+	"backend/algorithm"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// One Mermaid flowchart, with a subgraph per tree when there are several.
+// Arrows go from the ingredients to the element they craft, base elements
+// have the base class
+func WriteMermaid(w io.Writer, trees []*algorithm.RecipeTree) error {
+	var b strings.Builder
+	b.WriteString("flowchart BT\n")
+	b.WriteString("  classDef base fill:#e8f4e8,stroke:#4a8a4a\n")
+	next := 0
+	for i, tree := range trees {
+		indent := "  "
+		if len(trees) > 1 {
+			fmt.Fprintf(&b, "  subgraph tree%d [\"Tree %d\"]\n", i+1, i+1)
+			indent = "    "
+		}
+		writeMermaidNode(&b, tree.Root, indent, &next)
+		if len(trees) > 1 {
+			b.WriteString("  end\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Writes node and its ingredients, returns the Mermaid ID of node
+func writeMermaidNode(b *strings.Builder, node *algorithm.TreeNode, indent string, next *int) string {
+	id := fmt.Sprintf("n%d", *next)
+	*next++
+	fmt.Fprintf(b, "%s%s[\"%s\"]", indent, id, strings.ReplaceAll(node.Name, "\"", "#quot;"))
+	if len(node.Ingredients) == 0 {
+		b.WriteString(":::base")
+	}
+	b.WriteString("\n")
+	for _, ingredient := range node.Ingredients {
+		ingredientID := writeMermaidNode(b, ingredient, indent, next)
+		fmt.Fprintf(b, "%s%s --> %s\n", indent, ingredientID, id)
+	}
+	return id
+}
@@ -0,0 +1,41 @@
+// Package render turns recipe trees into text that can be pasted into docs
+// and issues: Graphviz DOT, Mermaid flowcharts and indented trees. It also
+// exports the recipe graph for Cytoscape, Gephi and Graphviz
+package render
+
+import (
+	"backend/algorithm"
+	"io"
+)
+
+type Format struct {
+	Name        string
+	Extension   string // Of exported files, without the dot
+	ContentType string // Without charset, the output is always UTF-8
+	Write       func(w io.Writer, trees []*algorithm.RecipeTree) error
+}
+
+var formats = []Format{
+	{"dot", "dot", "text/vnd.graphviz", WriteDOT},
+	{"mermaid", "mmd", "text/plain", WriteMermaid},
+	{"text", "txt", "text/plain", WriteText},
+	{"ascii", "txt", "text/plain", WriteASCII},
+}
+
+// Names of every format, in a stable order
+func Formats() []string {
+	names := make([]string, len(formats))
+	for i, format := range formats {
+		names[i] = format.Name
+	}
+	return names
+}
+
+func Lookup(name string) (Format, bool) {
+	for _, format := range formats {
+		if format.Name == name {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
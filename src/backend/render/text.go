@@ -0,0 +1,85 @@
+package render
+
+import (
+	"backend/algorithm"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Characters drawing the branches of an indented tree
+type TreeStyle struct {
+	Branch string // Before every ingredient but the last
+	Last   string // Before the last ingredient
+	Pipe   string // Below an ingredient that has siblings after it
+	Space  string // Below the last ingredient
+}
+
+var (
+	Unicode = TreeStyle{"├── ", "└── ", "│   ", "    "}
+	ASCII   = TreeStyle{"|-- ", "`-- ", "|   ", "    "}
+)
+
+// Indented tree per recipe, ingredients below the element they craft
+//
+//	# Tree 1 of 1: depth 2, 2 crafts
+//	Stone (tier 2)
+//	├── Lava (tier 1)
+//	│   ├── Earth
+//	│   └── Fire
+//	└── Air
+func WriteText(w io.Writer, trees []*algorithm.RecipeTree) error {
+	return writeTrees(w, trees, Unicode)
+}
+
+// Same as WriteText with plain ASCII branches
+//
+//	Stone (tier 2)
+//	|-- Lava (tier 1)
+//	|   |-- Earth
+//	|   `-- Fire
+//	`-- Air
+func WriteASCII(w io.Writer, trees []*algorithm.RecipeTree) error {
+	return writeTrees(w, trees, ASCII)
+}
+
+func writeTrees(w io.Writer, trees []*algorithm.RecipeTree, style TreeStyle) error {
+	var b strings.Builder
+	for i, tree := range trees {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# Tree %d of %d: depth %d, %d crafts\n", i+1, len(trees), tree.Depth, tree.Crafts)
+		writeNode(&b, tree.Root, "", "", style, TierLabel)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Writes the tree below node without header, label gives the text of every
+// node
+func WriteTree(w io.Writer, node *algorithm.TreeNode, style TreeStyle, label func(*algorithm.TreeNode) string) error {
+	var b strings.Builder
+	writeNode(&b, node, "", "", style, label)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeNode(b *strings.Builder, node *algorithm.TreeNode, prefix, childPrefix string, style TreeStyle, label func(*algorithm.TreeNode) string) {
+	b.WriteString(prefix + label(node) + "\n")
+	for i, ingredient := range node.Ingredients {
+		if i == len(node.Ingredients)-1 {
+			writeNode(b, ingredient, childPrefix+style.Last, childPrefix+style.Space, style, label)
+		} else {
+			writeNode(b, ingredient, childPrefix+style.Branch, childPrefix+style.Pipe, style, label)
+		}
+	}
+}
+
+// "Stone (tier 2)", base elements only have their name
+func TierLabel(node *algorithm.TreeNode) string {
+	if node.Tier > 0 {
+		return fmt.Sprintf("%s (tier %d)", node.Name, node.Tier)
+	}
+	return node.Name
+}
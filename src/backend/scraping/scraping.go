@@ -14,6 +14,13 @@ import (
 )
 
 var scrapingResultPath string = "scraping/recipes.json"
+var wikiURL string = "https://little-alchemy.fandom.com/wiki/Elements_(Little_Alchemy_2)"
+
+// Where the scraped recipes are written to and read from
+func SetResultPath(path string) { scrapingResultPath = path }
+
+// Page listing the elements and their recipes
+func SetWikiURL(url string) { wikiURL = url }
 
 type RecipeEntry struct {
 	Element []string              `json:"element"`
@@ -23,7 +30,7 @@ type RecipeEntry struct {
 }
 
 func ScrapeRecipes(scrapeIcon bool) error {
-	url := "https://little-alchemy.fandom.com/wiki/Elements_(Little_Alchemy_2)"
+	url := wikiURL
 	icons_path := "scraping/icons/"
 	startTime := time.Now()
 
@@ -31,6 +38,7 @@ func ScrapeRecipes(scrapeIcon bool) error {
 	doc, err := getHTMLDocument(url)
 	if err != nil {
 		fmt.Println("Error:", err)
+		return err
 	}
 
 	// JSON object to store the recipes
@@ -168,6 +176,11 @@ func ScrapeRecipes(scrapeIcon bool) error {
 		})
 	})
 
+	// Keep the previous recipes file rather than overwrite it with nothing
+	if len(recipesJSON.Element) == 0 {
+		return fmt.Errorf("no elements found on %s", url)
+	}
+
 	// Export the recipes to JSON file
 	filename, err := exportJSON(recipesJSON)
 	if err != nil {
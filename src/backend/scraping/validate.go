@@ -0,0 +1,97 @@
+package scraping
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Elements every recipe tree starts from, in the order of
+// search.RecipeGraph.BaseElements
+var BaseElements = []string{"Air", "Earth", "Fire", "Water"}
+
+// Problem found in a recipes file. Errors make the graph wrong, warnings are
+// usually quirks of the wiki
+type DatasetIssue struct {
+	Severity string `json:"severity"` // "error" or "warning"
+	Element  string `json:"element,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (i DatasetIssue) String() string {
+	if i.Element == "" {
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Element, i.Message)
+}
+
+// Checks that a recipes file describes a usable graph. Issues are sorted by
+// element
+func ValidateRecipes(recipesJSON RecipeEntry) []DatasetIssue {
+	issues := make([]DatasetIssue, 0)
+	report := func(severity, element, format string, args ...any) {
+		issues = append(issues, DatasetIssue{severity, element, fmt.Sprintf(format, args...)})
+	}
+
+	if len(recipesJSON.Element) == 0 {
+		report("error", "", "no elements")
+		return issues
+	}
+
+	known := make(map[string]bool, len(recipesJSON.Element))
+	for _, element := range recipesJSON.Element {
+		if element == "" {
+			report("error", "", "element with an empty name")
+		} else if known[element] {
+			report("error", element, "listed more than once")
+		}
+		known[element] = true
+	}
+	for _, base := range BaseElements {
+		if !known[base] {
+			report("error", base, "base element is missing")
+		}
+	}
+
+	for element := range recipesJSON.Recipe {
+		if !known[element] {
+			report("error", element, "has recipes but is not in the element list")
+		}
+	}
+	for element := range recipesJSON.Tiering {
+		if !known[element] {
+			report("warning", element, "has a tier but is not in the element list")
+		}
+	}
+
+	for _, element := range recipesJSON.Element {
+		if element == "" || slices.Contains(BaseElements, element) {
+			continue
+		}
+		recipes := recipesJSON.Recipe[element]
+		if len(recipes) == 0 {
+			report("warning", element, "has no recipe")
+			continue
+		}
+		tier, hasTier := recipesJSON.Tiering[element]
+		if !hasTier {
+			report("warning", element, "has no tier")
+		}
+		for _, recipe := range recipes {
+			if len(recipe) != 2 {
+				report("error", element, "recipe %v does not have two ingredients", recipe)
+				continue
+			}
+			for _, ingredient := range recipe {
+				if !known[ingredient] {
+					report("error", element, "unknown ingredient '%s'", ingredient)
+				} else if hasTier && recipesJSON.Tiering[ingredient] >= tier {
+					report("warning", element, "ingredient '%s' is not of a lower tier", ingredient)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Element < issues[j].Element })
+	return issues
+}
@@ -0,0 +1,19 @@
+package scraping
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Short hash identifying the content of a scraped dataset. Two scrapes of an
+// unchanged wiki give the same version
+func DatasetVersion(recipesJSON RecipeEntry) string {
+	// Maps are encoded with sorted keys, so the encoding is stable
+	data, err := json.Marshal(recipesJSON)
+	if err != nil {
+		return "unknown"
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,16 @@
+package search
+
+import "fmt"
+
+// No element has the requested name or ID
+type ElementNotFoundError struct {
+	Name string // Empty when looked up by ID
+	ID   int32
+}
+
+func (e *ElementNotFoundError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("element with ID %d not found", e.ID)
+	}
+	return fmt.Sprintf("element with name %s not found", e.Name)
+}
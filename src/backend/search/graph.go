@@ -13,6 +13,7 @@ type ElementNode struct {
 	ID       int              // Unique ID 0-720
 	Name     string           // Name of the element
 	Tier     int              // Tier 1-15. Base elements is tier 0
+	Icon     string           // Path of the scraped icon, empty when the icons were not scraped
 	Children []*ElementNode   // List of elements that can be created from this element
 	Recipes  [][]*ElementNode // Parents. List of pairs of elements that can be combined to create this element
 }
@@ -54,6 +55,7 @@ func ConstructRecipeGraph(recipesJSON scraping.RecipeEntry, graph *RecipeGraph)
 			Children: make([]*ElementNode, 0),
 			Recipes:  make([][]*ElementNode, 0),
 		}
+		node.Icon = recipesJSON.Icon[elementName]
 		if tier, ok := recipesJSON.Tiering[elementName]; ok {
 			node.Tier = tier
 		} else {
@@ -84,11 +86,10 @@ func ConstructRecipeGraph(recipesJSON scraping.RecipeEntry, graph *RecipeGraph)
 	}
 
 	// Set the base elements
-	graph.BaseElements = make([]*ElementNode, 4)
-	graph.BaseElements[0] = elementMap["Air"]
-	graph.BaseElements[1] = elementMap["Earth"]
-	graph.BaseElements[2] = elementMap["Fire"]
-	graph.BaseElements[3] = elementMap["Water"]
+	graph.BaseElements = make([]*ElementNode, len(scraping.BaseElements))
+	for i, name := range scraping.BaseElements {
+		graph.BaseElements[i] = elementMap[name]
+	}
 
 	return nil
 }
@@ -96,7 +97,7 @@ func ConstructRecipeGraph(recipesJSON scraping.RecipeEntry, graph *RecipeGraph)
 func GetElementByID(graph *RecipeGraph, id int32) (*ElementNode, error) {
 	// Return the element with the given ID
 	if id < 0 || int(id) >= len(graph.Elements) {
-		return nil, fmt.Errorf("element with ID %d not found", id)
+		return nil, &ElementNotFoundError{ID: id}
 	}
 	return graph.Elements[id], nil
 }
@@ -108,5 +109,5 @@ func GetElementByName(graph *RecipeGraph, name string) (*ElementNode, error) {
 			return element, nil
 		}
 	}
-	return nil, fmt.Errorf("element with name %s not found", name)
+	return nil, &ElementNotFoundError{Name: name}
 }
@@ -0,0 +1,97 @@
+package search_test
+
+import (
+	"backend/search"
+	"backend/search/graphtest"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestConstructRecipeGraph(t *testing.T) {
+	graph := graphtest.Diamond().Graph(t)
+
+	if root := search.GetRoot(graph); root.ID != 0 || root.Name != "" {
+		t.Errorf("element 0 is %q, want the sentinel", root.Name)
+	}
+	names := []string{"Air", "Earth", "Fire", "Water", "Lava", "Pressure", "Stone", "Sand"}
+	if len(graph.Elements) != len(names)+1 {
+		t.Fatalf("%d elements, want %d and the sentinel", len(graph.Elements), len(names))
+	}
+	for i, name := range names {
+		if element := graph.Elements[i+1]; element.ID != i+1 || element.Name != name {
+			t.Errorf("element %d is %d %s, want %s", i+1, element.ID, element.Name, name)
+		}
+	}
+	for i, name := range []string{"Air", "Earth", "Fire", "Water"} {
+		if base := graph.BaseElements[i]; base == nil || base.Name != name || base.Tier != 0 {
+			t.Errorf("base element %d is %v, want %s of tier 0", i, base, name)
+		}
+	}
+
+	stone, _ := search.GetElementByName(graph, "Stone")
+	if stone.Tier != 2 {
+		t.Errorf("Stone has tier %d, want 2", stone.Tier)
+	}
+	var recipes []string
+	for _, recipe := range stone.Recipes {
+		recipes = append(recipes, recipe[0].Name+"+"+recipe[1].Name)
+	}
+	if want := []string{"Lava+Air", "Earth+Pressure"}; !slices.Equal(recipes, want) {
+		t.Errorf("Stone has recipes %v, want %v", recipes, want)
+	}
+
+	// Air is in both recipes of Stone, Stone is a child of Air only once
+	air, _ := search.GetElementByName(graph, "Air")
+	var children []string
+	for _, child := range air.Children {
+		children = append(children, child.Name)
+	}
+	if want := []string{"Pressure", "Stone", "Sand"}; !slices.Equal(children, want) {
+		t.Errorf("Air has children %v, want %v", children, want)
+	}
+}
+
+func TestConstructRecipeGraphSkipsUnknownIngredients(t *testing.T) {
+	entry := graphtest.New().Add("Mud", "Water", "Earth").Entry()
+	entry.Recipe["Mud"] = append(entry.Recipe["Mud"], []string{"Water", "Dirt"})
+
+	graph := graphtest.Construct(t, entry)
+	mud, _ := search.GetElementByName(graph, "Mud")
+	if len(mud.Recipes) != 1 {
+		t.Errorf("Mud has %d recipes, want the one with known ingredients", len(mud.Recipes))
+	}
+}
+
+func TestConstructRecipeGraphUncraftable(t *testing.T) {
+	graph := graphtest.Pruned().Graph(t)
+	time, _ := search.GetElementByName(graph, "Time")
+	if len(time.Recipes) != 1 || time.Recipes[0][0] != search.GetRoot(graph) {
+		t.Errorf("Time has recipes %v, want the sentinel recipe", time.Recipes)
+	}
+	if time.Tier != 0 {
+		t.Errorf("Time has tier %d, want 0 without a tier in the file", time.Tier)
+	}
+}
+
+func TestGetElement(t *testing.T) {
+	graph := graphtest.Chain().Graph(t)
+
+	brick, err := search.GetElementByName(graph, "Brick")
+	if err != nil || brick.Name != "Brick" {
+		t.Fatalf("GetElementByName(Brick) = %v, %v", brick, err)
+	}
+	if element, err := search.GetElementByID(graph, int32(brick.ID)); err != nil || element != brick {
+		t.Errorf("GetElementByID(%d) = %v, %v", brick.ID, element, err)
+	}
+
+	var notFound *search.ElementNotFoundError
+	if _, err := search.GetElementByName(graph, "brick"); !errors.As(err, &notFound) || notFound.Name != "brick" {
+		t.Errorf("names are case sensitive, got %v", err)
+	}
+	for _, id := range []int32{-1, int32(len(graph.Elements))} {
+		if _, err := search.GetElementByID(graph, id); !errors.As(err, &notFound) || notFound.ID != id {
+			t.Errorf("GetElementByID(%d) = %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// Package graphtest builds recipe graphs for tests. Graphs always go
+// through search.ConstructRecipeGraph, so they look exactly like the ones
+// the server builds from a scraped recipes file
+package graphtest
+
+import (
+	"backend/scraping"
+	"backend/search"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// Recipes file under construction. The base elements are already added, with
+// the empty recipe the scraper gives them
+//
+//	b := graphtest.New()
+//	b.Add("Mud", "Water", "Earth")
+//	b.Add("Brick", "Mud", "Fire")
+//	graph := b.Graph(t)
+type Builder struct {
+	entry scraping.RecipeEntry
+	tiers map[string]int // Tiers set by hand, the others are computed
+}
+
+func New() *Builder {
+	b := &Builder{
+		entry: scraping.RecipeEntry{
+			Element: make([]string, 0),
+			Recipe:  make(map[string][][]string),
+			Tiering: make(map[string]int),
+			Icon:    make(map[string]string),
+		},
+		tiers: make(map[string]int),
+	}
+	for _, base := range scraping.BaseElements {
+		b.element(base)
+		b.entry.Recipe[base] = [][]string{{"", ""}}
+	}
+	return b
+}
+
+func (b *Builder) element(name string) {
+	if !slices.Contains(b.entry.Element, name) {
+		b.entry.Element = append(b.entry.Element, name)
+	}
+}
+
+// Adds the recipe result = ingredient0 + ingredient1. Elements are created
+// the first time they are named, in that order, which fixes their IDs
+func (b *Builder) Add(result, ingredient0, ingredient1 string) *Builder {
+	b.element(result)
+	b.element(ingredient0)
+	b.element(ingredient1)
+	b.entry.Recipe[result] = append(b.entry.Recipe[result], []string{ingredient0, ingredient1})
+	return b
+}
+
+// Adds an element the wiki lists without a recipe, like Time
+func (b *Builder) Uncraftable(name string) *Builder {
+	b.element(name)
+	b.entry.Recipe[name] = [][]string{{"", ""}}
+	return b
+}
+
+// Sets the tier of name instead of computing it, e.g. to break the tier rule
+func (b *Builder) Tier(name string, tier int) *Builder {
+	b.element(name)
+	b.tiers[name] = tier
+	return b
+}
+
+// The recipes file. Tiers not set by hand are the ones the wiki would give:
+// one more than the highest ingredient of the cheapest recipe. Elements
+// without a usable recipe get no tier
+func (b *Builder) Entry() scraping.RecipeEntry {
+	tiers := make(map[string]int, len(b.entry.Element))
+	for name, tier := range b.tiers {
+		tiers[name] = tier
+	}
+	for _, base := range scraping.BaseElements {
+		if _, ok := tiers[base]; !ok {
+			tiers[base] = 0
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, name := range b.entry.Element {
+			if _, ok := b.tiers[name]; ok || slices.Contains(scraping.BaseElements, name) {
+				continue
+			}
+			for _, recipe := range b.entry.Recipe[name] {
+				tier0, ok0 := tiers[recipe[0]]
+				tier1, ok1 := tiers[recipe[1]]
+				if !ok0 || !ok1 {
+					continue
+				}
+				if tier, ok := tiers[name]; !ok || max(tier0, tier1)+1 < tier {
+					tiers[name] = max(tier0, tier1) + 1
+					changed = true
+				}
+			}
+		}
+	}
+
+	entry := b.entry
+	entry.Element = slices.Clone(b.entry.Element)
+	entry.Recipe = make(map[string][][]string, len(b.entry.Recipe))
+	for name, recipes := range b.entry.Recipe {
+		entry.Recipe[name] = slices.Clone(recipes)
+	}
+	entry.Tiering = make(map[string]int, len(tiers))
+	for name, tier := range tiers {
+		// The scraper leaves the base elements out of the tiers
+		if !slices.Contains(scraping.BaseElements, name) {
+			entry.Tiering[name] = tier
+		}
+	}
+	return entry
+}
+
+func (b *Builder) Graph(t testing.TB) *search.RecipeGraph {
+	t.Helper()
+	return Construct(t, b.Entry())
+}
+
+func Construct(t testing.TB, entry scraping.RecipeEntry) *search.RecipeGraph {
+	t.Helper()
+	var graph search.RecipeGraph
+	if err := search.ConstructRecipeGraph(entry, &graph); err != nil {
+		t.Fatalf("constructing the graph: %v", err)
+	}
+	return &graph
+}
+
+// Graph of a recipes file, in the format the scraper writes
+func Load(t testing.TB, path string) *search.RecipeGraph {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	var entry scraping.RecipeEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("decoding %s: %v", path, err)
+	}
+	return Construct(t, entry)
+}
+
+// Path of the snapshot of the wiki shipped with this package, see
+// testdata/README.md
+func SnapshotPath() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "testdata", "recipes.json")
+}
+
+// Graph of the snapshot of the wiki, the dataset golden results are kept for
+func Snapshot(t testing.TB) *search.RecipeGraph {
+	t.Helper()
+	return Load(t, SnapshotPath())
+}
+
+// A few hand written graphs, each showing one shape the algorithms must handle
+func Chain() *Builder {
+	// Every element has one recipe, made of the previous one
+	return New().
+		Add("Mud", "Water", "Earth").
+		Add("Clay", "Mud", "Earth").
+		Add("Brick", "Clay", "Fire").
+		Add("Wall", "Brick", "Brick")
+}
+
+func Diamond() *Builder {
+	// Two recipes for Stone, both through a tier 1 element
+	return New().
+		Add("Lava", "Earth", "Fire").
+		Add("Pressure", "Air", "Air").
+		Add("Stone", "Lava", "Air").
+		Add("Stone", "Earth", "Pressure").
+		Add("Sand", "Stone", "Air")
+}
+
+func Pruned() *Builder {
+	// Recipes the search must skip: one breaking the tier rule, one using an
+	// element that cannot be crafted
+	return New().
+		Add("Steam", "Water", "Fire").
+		Add("Cloud", "Air", "Steam").
+		Add("Rain", "Cloud", "Water").
+		Add("Steam", "Rain", "Fire").
+		Uncraftable("Time").
+		Add("Rain", "Time", "Water")
+}
+
+// Random layered graph of size crafted elements. Every recipe uses lower
+// tiers, some elements have several recipes, and a few recipes break the
+// tier rule or use an uncraftable element, like the wiki does. The same seed
+// gives the same graph
+func Random(seed int64, size int) *Builder {
+	rng := rand.New(rand.NewSource(seed))
+	b := New().Uncraftable("Time")
+	names := slices.Clone(scraping.BaseElements)
+	for i := range size {
+		name := "E" + strconv.Itoa(i+1)
+		recipes := 1 + rng.Intn(3)
+		for range recipes {
+			b.Add(name, names[rng.Intn(len(names))], names[rng.Intn(len(names))])
+		}
+		switch rng.Intn(10) {
+		case 0:
+			b.Add(name, "Time", names[rng.Intn(len(names))])
+		case 1:
+			if i > 0 {
+				// Breaks the tier rule unless that element has a lower tier anyway
+				b.Add("E"+strconv.Itoa(rng.Intn(i)+1), name, names[rng.Intn(len(names))])
+			}
+		}
+		names = append(names, name)
+	}
+	return b
+}
@@ -0,0 +1,37 @@
+package graphtest_test
+
+import (
+	"backend/search/graphtest"
+	"testing"
+)
+
+func TestBuilderTiers(t *testing.T) {
+	entry := graphtest.Pruned().Tier("Cloud", 5).Entry()
+	want := map[string]int{"Steam": 1, "Cloud": 5, "Rain": 6}
+	for name, tier := range want {
+		if entry.Tiering[name] != tier {
+			t.Errorf("%s has tier %d, want %d", name, entry.Tiering[name], tier)
+		}
+	}
+	for _, name := range []string{"Air", "Time"} {
+		if _, ok := entry.Tiering[name]; ok {
+			t.Errorf("%s has a tier, the scraper gives it none", name)
+		}
+	}
+}
+
+func TestRandomIsReproducible(t *testing.T) {
+	a, b := graphtest.Random(7, 20).Entry(), graphtest.Random(7, 20).Entry()
+	for _, name := range a.Element {
+		if len(a.Recipe[name]) != len(b.Recipe[name]) || a.Tiering[name] != b.Tiering[name] {
+			t.Fatalf("%s differs between two graphs of the same seed", name)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	graph := graphtest.Snapshot(t)
+	if len(graph.Elements) != 48 {
+		t.Errorf("%d elements, want the 47 of the snapshot and the sentinel", len(graph.Elements))
+	}
+}
@@ -0,0 +1,40 @@
+package search
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// A loaded recipe graph and the dataset it was built from
+type Snapshot struct {
+	Graph    *RecipeGraph
+	Version  string // scraping.DatasetVersion of the recipes
+	Elements int    // Without the sentinel
+	LoadedAt time.Time
+}
+
+// Holds the graph the server answers from. Replacing it is atomic: a search
+// keeps the snapshot it started with while new requests get the new one
+type GraphStore struct {
+	current atomic.Pointer[Snapshot]
+}
+
+func NewGraphStore() *GraphStore {
+	return &GraphStore{}
+}
+
+// nil until the first graph is stored
+func (s *GraphStore) Current() *Snapshot {
+	return s.current.Load()
+}
+
+// Stores graph and returns its snapshot and the one it replaced, if any
+func (s *GraphStore) Swap(graph *RecipeGraph, version string) (*Snapshot, *Snapshot) {
+	snapshot := &Snapshot{
+		Graph:    graph,
+		Version:  version,
+		Elements: len(graph.Elements) - 1,
+		LoadedAt: time.Now(),
+	}
+	return snapshot, s.current.Swap(snapshot)
+}
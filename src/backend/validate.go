@@ -0,0 +1,60 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/search"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Largest body accepted by /api/validate, in bytes
+const maxValidateBodySize = 4 << 20
+
+// POST /api/validate with a tree submitted by the user. An invalid tree is
+// still a 200, the violations are the answer
+func handleValidate(datasets *search.GraphStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxValidateBodySize)
+		var request ValidateRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.Error(bodyTooLarge(tooLarge.Limit))
+				return
+			}
+			c.Error(invalidBody("Invalid body: %v", err))
+			return
+		}
+		if request.Tree == nil {
+			c.Error(invalidBody("Tree is required"))
+			return
+		}
+
+		graph := datasets.Current().Graph
+		owned := make([]*search.ElementNode, 0, len(request.Owned))
+		for _, name := range request.Owned {
+			element, err := search.GetElementByName(graph, name)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			owned = append(owned, element)
+		}
+
+		violations := algorithm.ValidateTree(graph, request.Tree, owned)
+		target := request.Tree.Target
+		if target == "" && request.Tree.Root != nil {
+			target = request.Tree.Root.Name
+		}
+		c.JSON(http.StatusOK, ValidateResponse{
+			Error: false,
+			Data: ValidateData{
+				Valid:      len(violations) == 0,
+				Target:     target,
+				Violations: violations,
+			},
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"backend/algorithm"
+	"backend/search"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Message sent by the client of a search session
+//
+//	{"action": "start", "element": "Brick", "max": 3}
+//	{"action": "step", "count": 5}
+//	{"action": "pause"}
+//	{"action": "resume", "interval": 200}
+//	{"action": "frontier"}
+//	{"action": "cancel"}
+type SessionCommand struct {
+	Action   string `json:"action"`
+	Element  string `json:"element"`
+	Max      int    `json:"max"`
+	Count    int    `json:"count"`    // step only, defaults to 1
+	Interval int    `json:"interval"` // resume only, milliseconds between expansions, defaults to 100
+}
+
+// Message sent to the client. Search progress uses the algorithm.SearchEvent
+// types, the session itself reports state, frontier and error messages
+type SessionMessage struct {
+	Type       string                   `json:"type"`
+	State      string                   `json:"state,omitempty"`
+	Frontier   []algorithm.FrontierItem `json:"frontier,omitempty"`
+	Expansions int                      `json:"expansions,omitempty"`
+	Message    string                   `json:"message,omitempty"`
+	Event      *algorithm.SearchEvent   `json:"event,omitempty"`
+}
+
+// Largest command a client may send, commands are a few dozen bytes
+const maxSessionCommandSize = 4096
+
+const (
+	sessionIdle      = "idle"
+	sessionPaused    = "paused"
+	sessionRunning   = "running"
+	sessionFinished  = "finished"
+	sessionCancelled = "cancelled"
+)
+
+// Accepts clients without an Origin header and the origins allowed by CORS
+func newUpgrader(origins []string) websocket.Upgrader {
+	return websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || slices.Contains(origins, origin)
+		},
+	}
+}
+
+type searchSessionConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	datasets *search.GraphStore
+	session  *algorithm.SearchSession
+	state    string
+	stop     chan struct{} // Closed to stop the running loop started by resume
+	done     chan struct{} // Closed once that loop returned
+	mu       sync.Mutex
+}
+
+// ws://localhost:8080/api/session. Sessions are closed as soon as shutdown
+// begins: server.Shutdown neither tracks nor waits for hijacked connections
+func handleSearchSession(shutdown context.Context, datasets *search.GraphStore, origins []string) gin.HandlerFunc {
+	upgrader := newUpgrader(origins)
+	return func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetReadLimit(maxSessionCommandSize)
+
+		s := &searchSessionConn{conn: conn, datasets: datasets, state: sessionIdle}
+		defer s.pause()
+
+		// Hijacked connections are not tracked by the server, so unblock
+		// ReadJSON ourselves when shutting down
+		closed := make(chan struct{})
+		defer close(closed)
+		go func() {
+			select {
+			case <-shutdown.Done():
+				s.writeMu.Lock()
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(time.Second))
+				s.writeMu.Unlock()
+				conn.Close()
+			case <-closed:
+			}
+		}()
+
+		for {
+			var command SessionCommand
+			if err := conn.ReadJSON(&command); err != nil {
+				return
+			}
+			if !s.handle(command) {
+				return
+			}
+		}
+	}
+}
+
+// Returns false if the message could not be written. The connection is then
+// closed, which ends the read loop and the session with it
+func (s *searchSessionConn) send(message SessionMessage) bool {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.WriteJSON(message); err != nil {
+		s.conn.Close()
+		return false
+	}
+	return true
+}
+
+func (s *searchSessionConn) sendState() {
+	s.mu.Lock()
+	message := SessionMessage{Type: "state", State: s.state}
+	if s.session != nil {
+		message.Expansions = s.session.Expansions()
+	}
+	s.mu.Unlock()
+	s.send(message)
+}
+
+func (s *searchSessionConn) sendError(format string, args ...any) {
+	s.send(SessionMessage{Type: "error", Message: fmt.Sprintf(format, args...)})
+}
+
+// Returns false once the connection should be closed
+func (s *searchSessionConn) handle(command SessionCommand) bool {
+	switch command.Action {
+	case "start":
+		// The search keeps this graph even if the dataset is reloaded meanwhile
+		graph := s.datasets.Current().Graph
+		node, err := search.GetElementByName(graph, command.Element)
+		if err != nil {
+			s.sendError("Element '%s' not found", command.Element)
+			return true
+		}
+		if command.Max <= 0 {
+			command.Max = 1
+		}
+
+		s.pause()
+		s.mu.Lock()
+		s.session = algorithm.NewSearchSession(node, graph, command.Max)
+		s.state = sessionPaused
+		s.mu.Unlock()
+		s.sendState()
+	case "step":
+		if !s.requireSession() {
+			return true
+		}
+		s.pause()
+		if command.Count <= 0 {
+			command.Count = 1
+		}
+		s.step(command.Count)
+		s.sendState()
+	case "pause":
+		if !s.requireSession() {
+			return true
+		}
+		s.pause()
+		s.sendState()
+	case "resume":
+		if !s.requireSession() {
+			return true
+		}
+		if command.Interval <= 0 {
+			command.Interval = 100
+		}
+		s.resume(time.Duration(command.Interval) * time.Millisecond)
+		s.sendState()
+	case "frontier":
+		if !s.requireSession() {
+			return true
+		}
+		s.mu.Lock()
+		session := s.session
+		s.mu.Unlock()
+		s.send(SessionMessage{Type: "frontier", Frontier: session.Frontier()})
+	case "cancel":
+		s.pause()
+		s.mu.Lock()
+		s.state = sessionCancelled
+		s.mu.Unlock()
+		s.sendState()
+		return false
+	default:
+		s.sendError("Unknown action '%s'", command.Action)
+	}
+	return true
+}
+
+// Sends an error if no search was started yet
+func (s *searchSessionConn) requireSession() bool {
+	s.mu.Lock()
+	started := s.session != nil
+	s.mu.Unlock()
+
+	if !started {
+		s.sendError("No search started")
+	}
+	return started
+}
+
+// Runs count expansions and sends their events. Returns false once the search
+// is over or the client is gone
+func (s *searchSessionConn) step(count int) bool {
+	s.mu.Lock()
+	session := s.session
+	s.mu.Unlock()
+
+	for _, event := range session.Step(count) {
+		if !s.send(SessionMessage{Type: event.Type, Event: &event}) {
+			return false
+		}
+	}
+	if session.Finished() {
+		s.mu.Lock()
+		s.state = sessionFinished
+		s.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+func (s *searchSessionConn) resume(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state != sessionPaused {
+		return
+	}
+	s.state = sessionRunning
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.stop, s.done = stop, done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if !s.step(1) {
+					s.sendState()
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Stops the loop started by resume and waits for its step in progress, so
+// no event is sent after the state that follows
+func (s *searchSessionConn) pause() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == sessionRunning {
+		s.state = sessionPaused
+	}
+}
@@ -0,0 +1,42 @@
+import type { GraphNode, GraphRecipe } from "./RecipeResult";
+
+// Canonical recipe tree returned by the backend for every algorithm.
+// See src/backend/algorithm/recipe_tree.schema.json
+export type TreeNode = {
+  id: number;
+  name: string;
+  tier: number;
+  depth: number;
+  recipe: number;
+  ingredients: TreeNode[];
+};
+
+export type RecipeTree = {
+  target: string;
+  depth: number;
+  crafts: number;
+  root: TreeNode;
+};
+
+export type RecipeGraphData = { nodes: GraphNode[]; recipes: GraphRecipe[] };
+
+// Flattens a recipe tree into the nodes and recipes drawn by RecipeResult.
+// Recipes are listed in pre-order so RecipeResult picks them back in the same order.
+export const treeToGraph = (tree: RecipeTree): RecipeGraphData => {
+  const nodes = new Map<number, GraphNode>();
+  const recipes: GraphRecipe[] = [];
+
+  const walk = (node: TreeNode) => {
+    nodes.set(node.id, { id: node.id, name: node.name });
+    if (node.ingredients.length === 0) return;
+    recipes.push({
+      ingredients: node.ingredients.map(ing => ing.name),
+      result: node.name,
+      step: node.depth,
+    });
+    node.ingredients.forEach(walk);
+  };
+  walk(tree.root);
+
+  return { nodes: Array.from(nodes.values()), recipes };
+};
@@ -3,7 +3,7 @@ import React, { useEffect, useState } from "react";
 import { useSearchParams, useRouter } from "next/navigation";
 import Navbar from "../../_components/Navbar";
 import RecipeResult from "../../_components/RecipeResult";
-import DFSRecipeResultMR from "../../_components/DFSRecipeResultMR";
+import { RecipeTree, RecipeGraphData, treeToGraph } from "../../_components/recipeTree";
 import config from "@/config";
 
 // Response types
@@ -13,45 +13,17 @@ type ErrorResponse = {
   message: string;
 };
 
-// BFS types
-type GraphNode = { id: number; name: string };
-type GraphRecipe = { ingredients: string[]; result: string; step: number };
-type BFSGraphData = { nodes: GraphNode[]; recipes: GraphRecipe[] };
-
-// DFS types
-type DFSNode = {
-  element: string;
-  recipe: string[];
-};
-
-type DFSGraphData = {
-  nodes: {
-    [key: string]: DFSNode;
-  };
-};
-
-// Success response types
-type SuccessResponseBFS = {
+type SuccessResponse = {
   error: false;
   data: {
     element: string;
     algo: string;
-    paths: BFSGraphData[];
+    paths: RecipeTree[];
     visitedNodes: number;
   };
 };
 
-type SuccessResponseDFS = {
-  error: false;
-  data: {
-    element: string;
-    algo: string;
-    paths: DFSGraphData[];
-    visitedNodes: number;
-  };
-};
-
-type ApiResponse = ErrorResponse | SuccessResponseBFS | SuccessResponseDFS;
+type ApiResponse = ErrorResponse | SuccessResponse;
 
 const MultiResult = () => {
   const params = useSearchParams();
@@ -62,7 +34,7 @@ const MultiResult = () => {
   const max = params.get("max") || "5";
   const [mode, setMode] = useState(2);
 
-  const [paths, setPaths] = useState<(BFSGraphData | DFSGraphData)[]>([]);
+  const [paths, setPaths] = useState<RecipeGraphData[]>([]);
   const [error, setError] = useState<string | null>(null);
   const [isLoading, setIsLoading] = useState<boolean>(true);
   const [elapsed, setElapsed] = useState<number | null>(null);
@@ -107,15 +79,12 @@ const MultiResult = () => {
           throw new Error(message || `Error: ${type}`);
         }
 
-        const { paths, visitedNodes } = json.data;
+        const { paths, visitedNodes } = (json as SuccessResponse).data;
         
         // Log detailed information about the paths
         console.log("Received paths count:", paths?.length);
         
-        // Process paths based on algorithm
-        const processedPaths = processPaths(paths, algo);
-        
-        setPaths(processedPaths);
+        setPaths((paths ?? []).map(treeToGraph));
         setElapsed(Math.round(t1 - t0));
         setVisited(visitedNodes);
         setError(null);
@@ -131,27 +100,6 @@ const MultiResult = () => {
     })();
   }, [element, algo, max]);
 
-  // Process the paths based on algorithm to handle backend response format differences
-  const processPaths = (paths: any[], algoType: string): any[] => {
-    if (!paths || !Array.isArray(paths)) {
-      console.error("Invalid paths data:", paths);
-      return [];
-    }
-
-    // For DFS algorithm, make sure each path has a nodes object
-    if (algoType === "dfs") {
-      return paths.map((path, index) => {
-        // Handle if path is just a nodes object directly
-        if (path && typeof path === 'object' && !path.nodes) {
-          return { nodes: path };
-        }
-        return path;
-      }).filter(Boolean);
-    }
-    
-    return paths;
-  };
-
   if (isLoading) {
     return (
       <div className="flex justify-center items-center min-h-[50vh]">
@@ -223,23 +171,12 @@ const MultiResult = () => {
         {paths.map((path, index) => {
           // Add debug output for investigating this specific path
           console.log(`Path #${index + 1}:`, path);
-          const hasBFSData = path.nodes && Array.isArray(path.nodes);
-          const hasDFSData = path.nodes && typeof path.nodes === 'object' && !Array.isArray(path.nodes);
           
           return (
             <div key={index} className="mb-10 w-full max-w-4xl">
               <h3 className="text-xl text-white mb-4">Path #{index + 1}</h3>
               
-              {hasBFSData && algo === "bfs" ? (
-                <RecipeResult graph={path as BFSGraphData} />
-              ) : hasDFSData || algo === "dfs" ? (
-                <DFSRecipeResultMR graph={path as DFSGraphData} />
-              ) : (
-                <div className="p-4 bg-red-900/30 rounded border border-red-500/50">
-                  <p className="text-white">Invalid graph data format for path #{index + 1}</p>
-                  <pre className="mt-2 text-xs text-gray-300 overflow-auto">{JSON.stringify(path, null, 2)}</pre>
-                </div>
-              )}
+              <RecipeResult graph={path} />
               
               <div className="border-b border-gray-700 my-8"></div>
             </div>
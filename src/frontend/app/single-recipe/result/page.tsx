@@ -2,7 +2,7 @@
 import { useEffect, useState } from "react";
 import { useSearchParams, useRouter } from "next/navigation";
 import RecipeResult from "../../_components/RecipeResult";
-import DFSRecipeResult from "../../_components/DFSRecipeResult";
+import { RecipeTree, RecipeGraphData, treeToGraph } from "../../_components/recipeTree";
 import Navbar from "../../_components/Navbar";
 import config from "@/config";
 
@@ -12,27 +12,14 @@ type ErrorResponse = {
   message: string;
 };
 
-type BFSGraphData = {
-  nodes: any[];
-  recipes: any[];
-  elapsed?: string;
-  visitedNodes?: number;
-};
-
-type DFSGraphData = {
-  nodes: {
-    [key: string]: {
-      element: string;
-      recipe: string[];
-    }
-  };
-  elapsed?: string;
-  visitedNodes?: number;
-};
-
 type SuccessResponse = {
   error: false;
-  data: BFSGraphData | DFSGraphData;
+  data: {
+    element: string;
+    algo: string;
+    paths: RecipeTree[];
+    visitedNodes: number;
+  };
 };
 
 type ApiResponse = ErrorResponse | SuccessResponse;
@@ -44,7 +31,7 @@ const Result = () => {
   const algo = params.get("algo")?.toLowerCase() || "bfs";
  
   const [mode, setMode] = useState(1);
-  const [data, setData] = useState<BFSGraphData | DFSGraphData | null>(null);
+  const [data, setData] = useState<RecipeGraphData | null>(null);
   const [error, setError] = useState<string | null>(null);
   const [isLoading, setIsLoading] = useState(true);
   const [elapsed, setElapsed] = useState<string>("0");
@@ -70,35 +57,13 @@ const Result = () => {
           const errorResponse = json as ErrorResponse;
           throw new Error(errorResponse.message || `Error: ${errorResponse.type}`);
         }
-        const successResponse = json as SuccessResponse;
-        let graphData = successResponse.data;
+        const { paths, visitedNodes } = (json as SuccessResponse).data;
         const elapsedTime = (performance.now() - t0).toFixed(2);
-        
-        graphData.elapsed = graphData.elapsed || elapsedTime;
-        
-        setElapsed(graphData.elapsed);
-        
-        // Set visited nodes based on algorithm
-        if (algo === "bfs" && "paths" in graphData) {
-          const firstPath = (graphData as any).paths?.[0] ?? { nodes: [], recipes: [] };
-          graphData = {
-            nodes:        firstPath.nodes,
-            recipes:      firstPath.recipes,
-            elapsed:      graphData.elapsed,
-            visitedNodes: graphData.visitedNodes,
-          } as BFSGraphData;
-        }
-        if (algo === "bfs"){
-          const bfsData = graphData as BFSGraphData;
-          setHasNoRecipe(!bfsData.recipes || bfsData.recipes.length === 0);
-          setVisitedNodes(bfsData.visitedNodes);
-        } else {
-          const dfsData = graphData as DFSGraphData;
-          setHasNoRecipe(Object.keys(dfsData.nodes).length === 0);
-          setVisitedNodes(dfsData.visitedNodes);
-        }
-        
-        console.log('Graph Data to be set:', graphData);
+        const graphData = paths.length > 0 ? treeToGraph(paths[0]) : { nodes: [], recipes: [] };
+
+        setElapsed(elapsedTime);
+        setHasNoRecipe(graphData.recipes.length === 0);
+        setVisitedNodes(visitedNodes);
         setData(graphData);
         setError(null);
       } catch (e: any) {
@@ -173,17 +138,7 @@ const Result = () => {
           <p>Visited nodes: {visitedNodes}</p>
         </div>
         
-        {algo === "bfs" ? (
-          <>
-            {console.log("Data yang dikirim ke RecipeResult:", data)}
-            <RecipeResult graph={data as BFSGraphData} />
-          </>
-        ) : (
-          <>
-            {console.log("Data yang dikirim ke DFSRecipeResult:", data)}
-            <DFSRecipeResult graph={data as DFSGraphData} />
-          </>
-        )}
+        <RecipeResult graph={data as RecipeGraphData} />
         
         <button
           className="m-[10px] p-[10px] w-[199px] h-[44px] border